@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"os"
+	_ "time/tzdata" // user time zones, the runtime image has no zoneinfo
 
 	"github.com/Ycyken/tournament-bot/internal/bot"
 	"github.com/Ycyken/tournament-bot/internal/service"
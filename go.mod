@@ -2,12 +2,14 @@ module github.com/Ycyken/tournament-bot
 
 go 1.24.3
 
-require gopkg.in/telebot.v3 v3.3.8
+require (
+	github.com/jackc/pgx/v5 v5.7.6
+	gopkg.in/telebot.v3 v3.3.8
+)
 
 require (
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
-	github.com/jackc/pgx/v5 v5.7.6 // indirect
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/lib/pq v1.10.9 // indirect
 	golang.org/x/crypto v0.37.0 // indirect
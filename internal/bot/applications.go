@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+	tb "gopkg.in/telebot.v3"
+)
+
+// myApplicationsShown limits the history on the "my applications" screen.
+const myApplicationsShown = 20
+
+func applicationStatusText(r *domain.ApplicationRecord) string {
+	switch {
+	case r.Status == domain.ApplicationPending && r.Waitlisted:
+		return "⏳ в листе ожидания"
+	case r.Status == domain.ApplicationPending:
+		return "🕓 на рассмотрении"
+	case r.Status == domain.ApplicationApproved:
+		return "✅ одобрена"
+	case r.Status == domain.ApplicationRejected && r.Reason != "":
+		return "❌ отклонена: " + r.Reason
+	case r.Status == domain.ApplicationRejected:
+		return "❌ отклонена"
+	}
+	return string(r.Status)
+}
+
+func myApplicationsView(records []*domain.ApplicationRecord, loc *time.Location) (string, *tb.ReplyMarkup) {
+	menu := &tb.ReplyMarkup{}
+	btnMain := menu.Data("Главное меню", MainMenu)
+	if len(records) == 0 {
+		menu.Inline(menu.Row(btnMain))
+		return "Вы пока не подавали заявок.", menu
+	}
+
+	var text strings.Builder
+	text.WriteString("Ваши заявки:\n\n")
+	var rows []tb.Row
+	for i, r := range records {
+		if i == myApplicationsShown {
+			fmt.Fprintf(&text, "…и ещё %d\n", len(records)-i)
+			break
+		}
+		fmt.Fprintf(&text, "«%s» (%s), %s — %s\n", r.Tournament, r.Name, r.At.In(loc).Format("02.01.2006"), applicationStatusText(r))
+		if r.Status == domain.ApplicationPending {
+			rows = append(rows, menu.Row(menu.Data("✏️ "+r.Tournament, fmt.Sprintf("myapp_%d", r.TournamentID))))
+		}
+	}
+	if len(rows) > 0 {
+		text.WriteString("\nЗаявки на рассмотрении можно изменить или отозвать.")
+	}
+	rows = append(rows, menu.Row(btnMain))
+	menu.Inline(rows...)
+	return text.String(), menu
+}
+
+func ownApplicationMenu(c tb.Context, t *domain.Tournament, app *domain.Application) error {
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(
+		menu.Row(menu.Data("Изменить имя", fmt.Sprintf("myapp_name_%d", t.ID))),
+		menu.Row(menu.Data("Изменить текст", fmt.Sprintf("myapp_text_%d", t.ID))),
+		menu.Row(menu.Data("🗑 Отозвать заявку", fmt.Sprintf("myapp_withdraw_%d", t.ID))),
+		menu.Row(menu.Data("⬅️ Мои заявки", MyApplications)),
+	)
+	return c.Edit(applicationText(t, app), menu)
+}
+
+// editApplication saves a new name or text of the user's pending
+// application; the other one is kept.
+func (b *Bot) editApplication(c tb.Context, name, text *string) error {
+	uid := domain.TelegramUserID(c.Sender().ID)
+	ac := b.getApply(uid)
+	if ac == nil {
+		b.setState(uid, StateMainMenu)
+		return c.Send("Сессия сброшена. Выберите действие", mainMenu())
+	}
+	app, err := b.svc.GetApplication(ac.TournamentID, uid)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	if app == nil {
+		b.clearApply(uid)
+		b.setState(uid, StateMainMenu)
+		return c.Send("Заявка уже рассмотрена.", mainMenu())
+	}
+
+	newName, newText := app.Name, ""
+	if app.Text != nil {
+		newText = *app.Text
+	}
+	if name != nil {
+		newName = *name
+	}
+	if text != nil {
+		newText = *text
+	}
+	_, err = b.svc.EditApplication(ac.TournamentID, uid, newName, newText)
+	if errors.Is(err, domain.ErrEmptyName) {
+		return c.Send("Имя не может быть пустым. Введите имя ещё раз:")
+	} else if errors.Is(err, domain.ErrApplicationNotFound) {
+		b.clearApply(uid)
+		b.setState(uid, StateMainMenu)
+		return c.Send("Заявка уже рассмотрена.", mainMenu())
+	} else if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	b.clearApply(uid)
+	b.setState(uid, StateMainMenu)
+
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data("⬅️ К заявке", fmt.Sprintf("myapp_%d", ac.TournamentID))))
+	return c.Send("✅ Заявка изменена.", menu)
+}
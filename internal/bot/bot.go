@@ -12,17 +12,42 @@ import (
 )
 
 const (
-	StateMainMenu               = "main_menu"
-	StateWaitingTournamentTitle = "waiting_tournament_title"
-	StateTournamentManagement
-	StateApplyEnterName    = "application_enter_name"
-	StateApplyEnterText    = "application_enter_text"
-	StateAdminAwaitMatchID = "admin_await_match_id"
+	StateMainMenu                = "main_menu"
+	StateWaitingTournamentTitle  = "waiting_tournament_title"
+	StateWaitingTournamentGame   = "waiting_tournament_game"
+	StateWaitingTournamentSystem = "waiting_tournament_system"
+	StateWaitingBracketReset     = "waiting_bracket_reset"
+	StateWaitingRoundRobinCycles = "waiting_round_robin_cycles"
+	StateTournamentManagement    = "tournament_management"
+	StateApplyEnterName          = "application_enter_name"
+	StateApplyEnterText          = "application_enter_text"
+	StateApplyFormField          = "application_form_field"
+	StateApplyEditName           = "application_edit_name"
+	StateApplyEditText           = "application_edit_text"
+	StateAdminAwaitMatchID       = "admin_await_match_id"
+	StateAdminAwaitMatchScore    = "admin_await_match_score"
+	StateAdminAwaitBestOf        = "admin_await_best_of"
+	StateAdminAwaitDeadline      = "admin_await_deadline"
+	StatePlayerAwaitScore        = "player_await_score"
+	StatePlayerAwaitEvidence     = "player_await_evidence"
+	StateAdminAwaitResolution    = "admin_await_resolution"
+	StateAdminAwaitRejectReason  = "admin_await_reject_reason"
+	StatePlayerAwaitMatchTime    = "player_await_match_time"
+	StateAdminAwaitScoring       = "admin_await_scoring"
+	StateAdminAwaitSeedOrder     = "admin_await_seed_order"
+	StateAdminAwaitRatings       = "admin_await_ratings"
+	StateAdminAwaitCapacity      = "admin_await_capacity"
+	StateAdminAwaitRegDeadline   = "admin_await_reg_deadline"
+	StateAdminAwaitFormQuestion  = "admin_await_form_question"
+	StateAdminAwaitProfileField  = "admin_await_profile_field"
+	StateAdminAwaitStartDate     = "admin_await_start_date"
 )
 
 type applyCtx struct {
 	TournamentID domain.TournamentID
 	Name         string
+	Step         int            // index of the form question being asked
+	Answers      map[int]string // by form field ID
 }
 
 func (b *Bot) setApply(uid domain.TelegramUserID, a *applyCtx) {
@@ -43,8 +68,40 @@ func (b *Bot) clearApply(uid domain.TelegramUserID) {
 	}
 }
 
+type createCtx struct {
+	Title  string
+	Game   string
+	System domain.System
+}
+
+func (b *Bot) setCreateCtx(uid domain.TelegramUserID, ctx *createCtx) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.create == nil {
+		b.create = make(map[domain.TelegramUserID]*createCtx)
+	}
+	b.create[uid] = ctx
+}
+
+func (b *Bot) getCreateCtx(uid domain.TelegramUserID) *createCtx {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.create[uid]
+}
+
+func (b *Bot) clearCreateCtx(uid domain.TelegramUserID) {
+	b.mu.Lock()
+	delete(b.create, uid)
+	b.mu.Unlock()
+}
+
 type adminSetResultCtx struct {
 	TournamentID domain.TournamentID
+	MatchID      domain.MatchID
+	Resolution   domain.Resolution
+	Applicant    domain.TelegramUserID
+	FieldKind    domain.FieldKind
+	ProfileField domain.ProfileField
 }
 
 func (b *Bot) setAdminCtx(uid domain.TelegramUserID, ctx *adminSetResultCtx) {
@@ -68,12 +125,41 @@ func (b *Bot) clearAdminCtx(uid domain.TelegramUserID) {
 	b.mu.Unlock()
 }
 
+type reportCtx struct {
+	TournamentID domain.TournamentID
+	MatchID      domain.MatchID
+}
+
+func (b *Bot) setReportCtx(uid domain.TelegramUserID, ctx *reportCtx) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.report == nil {
+		b.report = make(map[domain.TelegramUserID]*reportCtx)
+	}
+	b.report[uid] = ctx
+}
+
+func (b *Bot) getReportCtx(uid domain.TelegramUserID) *reportCtx {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.report[uid]
+}
+
+func (b *Bot) clearReportCtx(uid domain.TelegramUserID) {
+	b.mu.Lock()
+	delete(b.report, uid)
+	b.mu.Unlock()
+}
+
 type Bot struct {
 	bot    *tb.Bot
 	svc    *service.Service
 	states map[domain.TelegramUserID]string
 	apply  map[domain.TelegramUserID]*applyCtx
 	admin  map[domain.TelegramUserID]*adminSetResultCtx
+	create map[domain.TelegramUserID]*createCtx
+	report map[domain.TelegramUserID]*reportCtx
+	review map[domain.TelegramUserID]*reviewCtx
 	mu     sync.RWMutex
 }
 
@@ -93,11 +179,13 @@ func NewBot(svc *service.Service, token string) (*Bot, error) {
 	}
 
 	registerHandlers(bt)
+	svc.SetNotifier(bt)
 
 	return bt, nil
 }
 
 func (b *Bot) Run() {
+	go b.runScheduler()
 	b.bot.Start()
 	return
 }
@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+	tb "gopkg.in/telebot.v3"
+)
+
+// claimText describes what one side of m reported. The score is given
+// from P1's side, as it is stored.
+func claimText(t *domain.Tournament, m *domain.Match, res *domain.ResultType, games []domain.GameScore) string {
+	if res == nil {
+		return "результат не сообщил"
+	}
+	var text string
+	switch *res {
+	case domain.P1Won:
+		text = "победил " + t.FindParticipantByPID(m.P1).Name
+	case domain.P2Won:
+		text = "победил " + t.FindParticipantByPID(m.P2).Name
+	default:
+		text = "ничья"
+	}
+	if len(games) > 0 {
+		text += ", счёт " + domain.FormatScore(games)
+	}
+	return text
+}
+
+func resolutionName(t *domain.Tournament, m *domain.Match, r domain.Resolution) string {
+	switch r {
+	case domain.AcceptP1:
+		return "принят отчёт " + t.FindParticipantByPID(m.P1).Name
+	case domain.AcceptP2:
+		return "принят отчёт " + t.FindParticipantByPID(m.P2).Name
+	case domain.BothForfeit:
+		return "техническое поражение обоим"
+	}
+	return string(r)
+}
+
+func conflictText(t *domain.Tournament, m *domain.Match, evidence []*domain.Evidence) string {
+	p1, p2 := t.FindParticipantByPID(m.P1), t.FindParticipantByPID(m.P2)
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "⚖️ Спор по матчу #%d турнира «%s» (раунд %d)\n", m.ID, t.Title, m.Round)
+	fmt.Fprintf(&text, "%s vs %s\n\n", p1.Name, p2.Name)
+	fmt.Fprintf(&text, "%s: %s\n", p1.Name, claimText(t, m, m.OpinionP1, m.ClaimP1))
+	fmt.Fprintf(&text, "%s: %s\n", p2.Name, claimText(t, m, m.OpinionP2, m.ClaimP2))
+
+	if len(evidence) > 0 {
+		text.WriteString("\nДоказательства:\n")
+		for _, e := range evidence {
+			from := evidenceAuthor(t, e)
+			switch {
+			case e.PhotoFileID != "" && e.Text != "":
+				fmt.Fprintf(&text, "• %s: 📷 фото — %s\n", from, e.Text)
+			case e.PhotoFileID != "":
+				fmt.Fprintf(&text, "• %s: 📷 фото\n", from)
+			default:
+				fmt.Fprintf(&text, "• %s: %s\n", from, e.Text)
+			}
+		}
+	}
+	return text.String()
+}
+
+func conflictMenu(t *domain.Tournament, m *domain.Match, photos int) *tb.ReplyMarkup {
+	p1, p2 := t.FindParticipantByPID(m.P1), t.FindParticipantByPID(m.P2)
+
+	menu := &tb.ReplyMarkup{}
+	btnP1 := menu.Data("✅ Прав "+p1.Name, fmt.Sprintf("conflict_pick_%d_%d_%s", t.ID, m.ID, domain.AcceptP1))
+	btnP2 := menu.Data("✅ Прав "+p2.Name, fmt.Sprintf("conflict_pick_%d_%d_%s", t.ID, m.ID, domain.AcceptP2))
+	btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("adm_matches_tournament%d", t.ID))
+
+	rows := []tb.Row{menu.Row(btnP1), menu.Row(btnP2)}
+	if !t.System.Elimination() {
+		rows = append(rows, menu.Row(menu.Data("❌ Поражение обоим", fmt.Sprintf("conflict_pick_%d_%d_%s", t.ID, m.ID, domain.BothForfeit))))
+	}
+	if photos > 0 {
+		rows = append(rows, menu.Row(menu.Data(fmt.Sprintf("🖼 Фото (%d)", photos), fmt.Sprintf("conflict_photos_%d_%d", t.ID, m.ID))))
+	}
+	rows = append(rows, menu.Row(btnBack))
+	menu.Inline(rows...)
+	return menu
+}
+
+func countPhotos(evidence []*domain.Evidence) int {
+	var n int
+	for _, e := range evidence {
+		if e.PhotoFileID != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// notifyConflict tells the judges about a fresh conflict and invites both
+// sides to back their report with evidence.
+func (b *Bot) notifyConflict(t *domain.Tournament, m *domain.Match) {
+	for _, uid := range t.StaffWith(domain.PermResults) {
+		b.notify(uid, conflictText(t, m, nil), conflictMenu(t, m, 0))
+	}
+
+	for _, id := range []domain.ParticipantID{m.P1, m.P2} {
+		menu := &tb.ReplyMarkup{}
+		menu.Inline(menu.Row(menu.Data("📎 Приложить доказательство", fmt.Sprintf("evidence_%d_%d", t.ID, m.ID))))
+		for _, uid := range t.FindParticipantByPID(id).Roster {
+			b.notify(uid, conflictText(t, m, nil)+"\nОтчёты не совпали, организатор рассмотрит спор. Вы можете приложить комментарий или скриншот.", menu)
+		}
+	}
+}
+
+func (b *Bot) notifyResolution(t *domain.Tournament, m *domain.Match) {
+	text := fmt.Sprintf("⚖️ Организатор решил спор по матчу #%d турнира «%s»: %s.", m.ID, t.Title, resolutionName(t, m, m.Resolution))
+	if m.ResolutionNote != "" {
+		text += "\nПричина: " + m.ResolutionNote
+	}
+	for _, id := range []domain.ParticipantID{m.P1, m.P2} {
+		for _, uid := range t.FindParticipantByPID(id).Roster {
+			b.notify(uid, text)
+		}
+	}
+}
+
+// evidenceAuthor names the participant who sent e, or "?" when the sender
+// is no longer found among the participants.
+func evidenceAuthor(t *domain.Tournament, e *domain.Evidence) string {
+	if p := t.FindParticipantBytgID(e.UserID); p != nil {
+		return p.Name
+	}
+	return "?"
+}
+
+// forwardEvidence shows the judges a piece of evidence as soon as it arrives.
+func (b *Bot) forwardEvidence(t *domain.Tournament, e *domain.Evidence) {
+	caption := fmt.Sprintf("📎 %s по матчу #%d", evidenceAuthor(t, e), e.MatchID)
+	if e.Text != "" {
+		caption += ": " + e.Text
+	}
+
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data("⚖️ Открыть спор", fmt.Sprintf("conflict_view_%d_%d", t.ID, e.MatchID))))
+	for _, uid := range t.StaffWith(domain.PermResults) {
+		if e.PhotoFileID == "" {
+			b.notify(uid, caption, menu)
+			continue
+		}
+		photo := &tb.Photo{File: tb.File{FileID: e.PhotoFileID}, Caption: caption}
+		if _, err := b.bot.Send(&tb.User{ID: int64(uid)}, photo, menu); err != nil {
+			log.Printf("failed to forward evidence to %d: %v", uid, err)
+		}
+	}
+}
+
+func (b *Bot) addEvidence(c tb.Context, e *domain.Evidence) error {
+	t, err := b.svc.AddEvidence(e)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	b.setState(e.UserID, StateMainMenu)
+	b.clearReportCtx(e.UserID)
+	b.forwardEvidence(t, e)
+
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data("📎 Добавить ещё", fmt.Sprintf("evidence_%d_%d", t.ID, e.MatchID))))
+	return c.Send("✅ Доказательство отправлено организатору.", menu)
+}
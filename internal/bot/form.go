@@ -0,0 +1,199 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+	tb "gopkg.in/telebot.v3"
+)
+
+func fieldKindName(k domain.FieldKind) string {
+	switch k {
+	case domain.FieldText:
+		return "текст"
+	case domain.FieldNumber:
+		return "число"
+	case domain.FieldChoice:
+		return "выбор из вариантов"
+	case domain.FieldYesNo:
+		return "да/нет"
+	}
+	return string(k)
+}
+
+func answerText(v string) string {
+	switch v {
+	case domain.AnswerYes:
+		return "да"
+	case domain.AnswerNo:
+		return "нет"
+	}
+	return v
+}
+
+// answersText lists the answers of the application in the order of the form.
+func answersText(t *domain.Tournament, app *domain.Application) string {
+	var text strings.Builder
+	for _, f := range t.Form {
+		v, ok := app.Answers[f.ID]
+		if !ok {
+			v = "—"
+		}
+		fmt.Fprintf(&text, "%s: %s\n", f.Question, answerText(v))
+	}
+	return text.String()
+}
+
+func formText(t *domain.Tournament) string {
+	var text strings.Builder
+	text.WriteString("Анкета участника. Кроме имени и текста заявки, участник ответит на эти вопросы:\n\n")
+	if len(t.Form) == 0 {
+		text.WriteString("Вопросов пока нет.\n")
+	}
+	for i, f := range t.Form {
+		required := ""
+		if f.Required {
+			required = ", обязательный"
+		}
+		fmt.Fprintf(&text, "%d. %s (%s%s)\n", i+1, f.Question, fieldKindName(f.Kind), required)
+		if len(f.Options) > 0 {
+			fmt.Fprintf(&text, "   Варианты: %s\n", strings.Join(f.Options, ", "))
+		}
+	}
+	text.WriteString("\nОбязательный вопрос «да/нет» нужно подтвердить ответом «да», например согласие с правилами.")
+	return text.String()
+}
+
+func formMenu(c tb.Context, t *domain.Tournament) error {
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+	for i, f := range t.Form {
+		required := "☆ Необязательный"
+		if f.Required {
+			required = "⭐ Обязательный"
+		}
+		rows = append(rows, menu.Row(
+			menu.Data(fmt.Sprintf("%d. %s", i+1, required), fmt.Sprintf("form_req_%d_%d_%t", t.ID, f.ID, !f.Required)),
+			menu.Data("🗑", fmt.Sprintf("form_del_%d_%d", t.ID, f.ID)),
+		))
+	}
+	rows = append(rows,
+		menu.Row(menu.Data("➕ Добавить вопрос", fmt.Sprintf("form_add_%d", t.ID))),
+		menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("reg_tournament%d", t.ID))),
+	)
+	menu.Inline(rows...)
+	return c.Edit(formText(t), menu)
+}
+
+func fieldKindMenu(tID domain.TournamentID) *tb.ReplyMarkup {
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+	for _, k := range domain.FieldKinds {
+		rows = append(rows, menu.Row(menu.Data(fieldKindName(k), fmt.Sprintf("form_kind_%d_%s", tID, k))))
+	}
+	rows = append(rows, menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("form_tournament%d", tID))))
+	menu.Inline(rows...)
+	return menu
+}
+
+// askNextField asks the applicant the next question of the form, or for
+// the free text of the application once the form is done.
+func (b *Bot) askNextField(c tb.Context, ac *applyCtx) error {
+	uid := domain.TelegramUserID(c.Sender().ID)
+	t, err := b.svc.GetTournament(ac.TournamentID)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+
+	menu := &tb.ReplyMarkup{}
+	btnCancel := menu.Data("Отменить", MainMenu)
+	if ac.Step >= len(t.Form) {
+		b.setState(uid, StateApplyEnterText)
+		menu.Inline(menu.Row(menu.Data("Пропустить", ApplySkipText), btnCancel))
+		return c.Send("Напишите текст заявки, либо нажмите «Пропустить».", menu)
+	}
+
+	b.setState(uid, StateApplyFormField)
+	f := t.Form[ac.Step]
+	text := fmt.Sprintf("Вопрос %d из %d: %s", ac.Step+1, len(t.Form), f.Question)
+
+	var rows []tb.Row
+	switch f.Kind {
+	case domain.FieldChoice:
+		for i, o := range f.Options {
+			rows = append(rows, menu.Row(menu.Data(o, fmt.Sprintf("apply_opt_%d", i+1))))
+		}
+	case domain.FieldYesNo:
+		btnYes := menu.Data("Да", "apply_opt_"+domain.AnswerYes)
+		if f.Required {
+			rows = append(rows, menu.Row(btnYes))
+		} else {
+			rows = append(rows, menu.Row(btnYes, menu.Data("Нет", "apply_opt_"+domain.AnswerNo)))
+		}
+	case domain.FieldNumber:
+		text += "\n\nОтветьте числом."
+	}
+	if f.Required {
+		rows = append(rows, menu.Row(btnCancel))
+	} else {
+		rows = append(rows, menu.Row(menu.Data("Пропустить", "apply_opt_skip"), btnCancel))
+	}
+	menu.Inline(rows...)
+	return c.Send(text, menu)
+}
+
+// answerField records the answer to the current question of the form and
+// moves on to the next one.
+func (b *Bot) answerField(c tb.Context, input string) error {
+	uid := domain.TelegramUserID(c.Sender().ID)
+	ac := b.getApply(uid)
+	if ac == nil {
+		b.setState(uid, StateMainMenu)
+		return c.Send("Сессия сброшена. Выберите действие", mainMenu())
+	}
+	t, err := b.svc.GetTournament(ac.TournamentID)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	if ac.Step >= len(t.Form) {
+		return b.askNextField(c, ac)
+	}
+
+	f := t.Form[ac.Step]
+	if f.Kind == domain.FieldYesNo {
+		// the answer may be typed instead of pressing a button
+		switch strings.ToLower(strings.TrimSpace(input)) {
+		case "да":
+			input = domain.AnswerYes
+		case "нет":
+			input = domain.AnswerNo
+		}
+	}
+	v, err := f.Parse(input)
+	if errors.Is(err, domain.ErrAnswerRequired) {
+		return c.Send("Это обязательный вопрос, ответьте на него.")
+	} else if errors.Is(err, domain.ErrMustAgree) {
+		return c.Send("Без согласия подать заявку нельзя.")
+	} else if errors.Is(err, domain.ErrInvalidAnswer) {
+		switch f.Kind {
+		case domain.FieldNumber:
+			return c.Send("Нужно число. Попробуйте ещё раз.")
+		case domain.FieldText:
+			return c.Send(fmt.Sprintf("Ответ длиннее %d символов. Напишите короче.", domain.MaxAnswerLength))
+		}
+		return c.Send("Выберите один из вариантов на кнопках.")
+	} else if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+
+	if ac.Answers == nil {
+		ac.Answers = make(map[int]string)
+	}
+	if v != "" {
+		ac.Answers[f.ID] = v
+	}
+	ac.Step++
+	return b.askNextField(c, ac)
+}
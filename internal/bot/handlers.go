@@ -2,10 +2,12 @@
 package bot
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Ycyken/tournament-bot/internal/domain"
 	tb "gopkg.in/telebot.v3"
@@ -14,9 +16,34 @@ import (
 func registerHandlers(bt *Bot) {
 	bot := bt.bot
 	bot.Handle("/start", func(c tb.Context) error {
+		if code, ok := strings.CutPrefix(c.Message().Payload, "team_"); ok {
+			return bt.joinTeam(c, code)
+		}
+		if code, ok := strings.CutPrefix(c.Message().Payload, "staff_"); ok {
+			return bt.joinStaff(c, code)
+		}
 		return c.Send("Выберите действие", mainMenu())
 	})
 
+	bot.Handle("/join", func(c tb.Context) error {
+		if c.Message().Payload == "" {
+			return c.Send("Укажите код приглашения: /join КОД")
+		}
+		return bt.joinTeam(c, c.Message().Payload)
+	})
+
+	bot.Handle("/timezone", func(c tb.Context) error {
+		userID := domain.TelegramUserID(c.Sender().ID)
+		if tz := strings.TrimSpace(c.Message().Payload); tz != "" {
+			if err := bt.svc.SetUserTimezone(userID, tz); err != nil {
+				return c.Send("Не знаю такой часовой пояс. Пример: /timezone Europe/Moscow")
+			}
+			return c.Send("✅ Часовой пояс: "+tz, mainMenu())
+		}
+		text, menu := timezoneMenu(bt.userLocation(userID))
+		return c.Send(text, menu)
+	})
+
 	bot.Handle(tb.OnCallback, func(c tb.Context) error {
 		_ = c.Respond(&tb.CallbackResponse{})
 
@@ -42,6 +69,79 @@ func registerHandlers(bt *Bot) {
 				return c.Send("Ошибка: " + err.Error())
 			}
 			return allTournamentsPage(c, tournaments, 0)
+		case TimezoneMenu:
+			text, menu := timezoneMenu(bt.userLocation(userID))
+			return c.Edit(text, menu)
+		case MyApplications:
+			records, err := bt.svc.GetUserApplications(userID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			text, menu := myApplicationsView(records, bt.userLocation(userID))
+			return c.Edit(text, menu)
+		case MyRating:
+			ratings, err := bt.svc.GetUserRatings(userID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			history, err := bt.svc.GetRatingHistory(userID, ratingHistorySize)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return c.Edit(ratingText(ratings, history), backToMainMenu())
+		case CreateGameSkip:
+			cc := bt.getCreateCtx(userID)
+			if cc == nil || bt.getState(userID) != StateWaitingTournamentGame {
+				bt.setState(userID, StateMainMenu)
+				return c.Edit("Сессия сброшена. Выберите действие", mainMenu())
+			}
+			bt.setState(userID, StateWaitingTournamentSystem)
+			return c.Edit("Выберите систему проведения турнира:", systemMenu())
+		}
+
+		if strings.HasPrefix(data, CreateSystemPrefix) {
+			system := domain.System(strings.TrimPrefix(data, CreateSystemPrefix))
+			cc := bt.getCreateCtx(userID)
+			if cc == nil || bt.getState(userID) != StateWaitingTournamentSystem {
+				bt.setState(userID, StateMainMenu)
+				return c.Edit("Сессия сброшена. Выберите действие", mainMenu())
+			}
+			cc.System = system
+			if system == domain.DoubleElimination {
+				bt.setState(userID, StateWaitingBracketReset)
+				return c.Edit("Переигрывать гранд-финал, если его выиграет победитель нижней сетки?", bracketResetMenu())
+			}
+			if system == domain.RoundRobin {
+				bt.setState(userID, StateWaitingRoundRobinCycles)
+				return c.Edit("Сколько кругов играть? Во втором круге участники меняются сторонами.", roundRobinCyclesMenu())
+			}
+			t := domain.NewTournament(userID, cc.Title, system)
+			t.Game = cc.Game
+			return bt.createTournament(c, t)
+		}
+
+		if strings.HasPrefix(data, CreateResetPrefix) {
+			cc := bt.getCreateCtx(userID)
+			if cc == nil || bt.getState(userID) != StateWaitingBracketReset {
+				bt.setState(userID, StateMainMenu)
+				return c.Edit("Сессия сброшена. Выберите действие", mainMenu())
+			}
+			t := domain.NewTournament(userID, cc.Title, cc.System)
+			t.Game = cc.Game
+			t.BracketReset = strings.TrimPrefix(data, CreateResetPrefix) == "1"
+			return bt.createTournament(c, t)
+		}
+
+		if strings.HasPrefix(data, CreateCyclesPrefix) {
+			cc := bt.getCreateCtx(userID)
+			if cc == nil || bt.getState(userID) != StateWaitingRoundRobinCycles {
+				bt.setState(userID, StateMainMenu)
+				return c.Edit("Сессия сброшена. Выберите действие", mainMenu())
+			}
+			t := domain.NewTournament(userID, cc.Title, cc.System)
+			t.Game = cc.Game
+			t.DoubleRoundRobin = strings.TrimPrefix(data, CreateCyclesPrefix) == "2"
+			return bt.createTournament(c, t)
 		}
 
 		if strings.HasPrefix(data, "myts_page_") {
@@ -90,28 +190,40 @@ func registerHandlers(bt *Bot) {
 			if err != nil {
 				return c.Send("Ошибка при получении заявки: " + err.Error())
 			}
-			t, _ := bt.svc.GetTournament(tID)
+			if app == nil {
+				return c.Send("Заявка уже рассмотрена")
+			}
+			t, err := bt.svc.GetTournament(tID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
 
 			tag := ""
 			if app.TelegramTag != nil {
 				tag = "(@" + *app.TelegramTag + ")"
 			}
 
-			if err := bt.svc.ApproveApplication(tID, tgID); err != nil {
+			if err := bt.svc.ApproveApplication(tID, userID, tgID); errors.Is(err, domain.ErrTournamentFull) {
+				return c.Send("Все места заняты. Увеличьте лимит участников в настройках регистрации.")
+			} else if errors.Is(err, domain.ErrApplicationNotFound) {
+				_ = c.Send("Заявка уже рассмотрена или отозвана.")
+				return bt.showApplications(c, tID)
+			} else if err != nil {
 				return c.Send("Ошибка при одобрении: " + err.Error())
 			}
 
 			_ = c.Send(fmt.Sprintf("✅ Заявка пользователя %s%s на турнир «%s» одобрена!", app.Name, tag, t.Title))
-
-			apps, err := bt.svc.GetApplications(tID)
-			if err != nil {
-				return c.Send("Ошибка: " + err.Error())
+			for _, uid := range app.Roster() {
+				bt.notify(uid, fmt.Sprintf("✅ Ваша заявка на турнир «%s» принята!", t.Title))
 			}
-			return applicationMenu(c, t, apps)
+
+			return bt.showApplications(c, tID)
 		}
 
-		if strings.HasPrefix(data, "app_reject_") {
-			parts := strings.Split(strings.TrimPrefix(data, "app_reject_"), "_")
+		if strings.HasPrefix(data, "app_reject_") || strings.HasPrefix(data, "app_rejectok_") {
+			confirmed := strings.HasPrefix(data, "app_rejectok_")
+			rest := strings.TrimPrefix(strings.TrimPrefix(data, "app_reject_"), "app_rejectok_")
+			parts := strings.Split(rest, "_")
 			if len(parts) != 2 {
 				return c.Send("Ошибка: Некорректные данные кнопки")
 			}
@@ -125,28 +237,95 @@ func registerHandlers(bt *Bot) {
 			tID := domain.TournamentID(tID64)
 			tgID := domain.TelegramUserID(tgID64)
 
-			app, err := bt.svc.GetApplication(tID, tgID)
-			if err != nil {
-				return c.Send("Ошибка при получении заявки: " + err.Error())
+			if confirmed {
+				return bt.rejectApplication(c, tID, tgID, "")
 			}
-			t, _ := bt.svc.GetTournament(tID)
 
-			tag := ""
-			if app.TelegramTag != nil {
-				tag = "(@" + *app.TelegramTag + ")"
+			if _, err := bt.svc.GetManagedTournament(tID, userID, domain.PermApplications); err != nil {
+				return c.Send("Ошибка: " + err.Error())
 			}
+			bt.setState(userID, StateAdminAwaitRejectReason)
+			bt.setAdminCtx(userID, &adminSetResultCtx{TournamentID: tID, Applicant: tgID})
 
-			if err := bt.svc.RejectApplication(tID, tgID); err != nil {
-				return c.Send("Ошибка при отклонении: " + err.Error())
+			menu := &tb.ReplyMarkup{}
+			btnSkip := menu.Data("Без причины", fmt.Sprintf("app_rejectok_%d_%d", tID, tgID))
+			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("applications_tournament%d", tID))
+			menu.Inline(menu.Row(btnSkip), menu.Row(btnBack))
+			return c.Edit("Напишите причину отказа — её увидит участник.", menu)
+		}
+
+		if strings.HasPrefix(data, "myapp_") {
+			action, id, ok := strings.Cut(strings.TrimPrefix(data, "myapp_"), "_")
+			if !ok {
+				action, id = "", action
+			}
+			tID64, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
 			}
+			tID := domain.TournamentID(tID64)
 
-			_ = c.Send(fmt.Sprintf("❌ Заявка пользователя %s%s на турнир «%s» отклонена.", app.Name, tag, t.Title))
+			switch action {
+			case "name", "text":
+				bt.setApply(userID, &applyCtx{TournamentID: tID})
+				menu := &tb.ReplyMarkup{}
+				menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("myapp_%d", tID))))
+				if action == "name" {
+					bt.setState(userID, StateApplyEditName)
+					return c.Edit("Напишите новое имя для заявки:", menu)
+				}
+				bt.setState(userID, StateApplyEditText)
+				return c.Edit("Напишите новый текст заявки. Чтобы убрать текст, отправьте «-».", menu)
+			case "withdraw":
+				menu := &tb.ReplyMarkup{}
+				btnYes := menu.Data("Да, отозвать", fmt.Sprintf("myapp_withdrawok_%d", tID))
+				btnNo := menu.Data("Отмена", fmt.Sprintf("myapp_%d", tID))
+				menu.Inline(menu.Row(btnYes, btnNo))
+				return c.Edit("Отозвать заявку? Подать её снова можно, пока идёт регистрация.", menu)
+			case "withdrawok":
+				app, err := bt.svc.WithdrawApplication(tID, userID)
+				if errors.Is(err, domain.ErrApplicationNotFound) {
+					return c.Send("Заявка уже рассмотрена или отозвана.", mainMenu())
+				} else if err != nil {
+					return c.Send("Ошибка: " + err.Error())
+				}
+				if t, err := bt.svc.GetTournament(tID); err == nil {
+					for _, uid := range t.StaffWith(domain.PermApplications) {
+						bt.notify(uid, fmt.Sprintf("🗑 %s отозвал(а) заявку на турнир «%s».", app.Name, t.Title))
+					}
+				}
+				records, err := bt.svc.GetUserApplications(userID)
+				if err != nil {
+					return c.Send("Ошибка: " + err.Error())
+				}
+				text, menu := myApplicationsView(records, bt.userLocation(userID))
+				return c.Edit("Заявка отозвана.\n\n"+text, menu)
+			}
 
-			apps, err := bt.svc.GetApplications(tID)
+			bt.setState(userID, StateMainMenu)
+			t, err := bt.svc.GetTournament(tID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			app, err := bt.svc.GetApplication(tID, userID)
 			if err != nil {
 				return c.Send("Ошибка: " + err.Error())
 			}
-			return applicationMenu(c, t, apps)
+			if app == nil {
+				return c.Edit("Заявка уже рассмотрена.", backToMainMenu())
+			}
+			return ownApplicationMenu(c, t, app)
+		}
+
+		if strings.HasPrefix(data, "apply_opt_") {
+			if bt.getState(userID) != StateApplyFormField {
+				return c.Send("Этот вопрос уже не актуален.")
+			}
+			v := strings.TrimPrefix(data, "apply_opt_")
+			if v == "skip" {
+				v = ""
+			}
+			return bt.answerField(c, v)
 		}
 
 		if data == ApplySkipText {
@@ -161,13 +340,24 @@ func registerHandlers(bt *Bot) {
 				Name:           ac.Name,
 				TelegramTag:    &c.Sender().Username,
 				Text:           nil,
+				Answers:        ac.Answers,
 			}
-			if err := bt.svc.ApplyToTournament(app); err != nil {
+			admitted, err := bt.svc.ApplyToTournament(app)
+			if errors.Is(err, domain.ErrRegistrationShut) {
+				bt.clearApply(userID)
+				bt.setState(userID, StateMainMenu)
+				return c.Edit("Приём заявок на турнир уже закрыт.", mainMenu())
+			} else if errors.Is(err, domain.ErrAnswerRequired) || errors.Is(err, domain.ErrMustAgree) || errors.Is(err, domain.ErrInvalidAnswer) {
+				bt.clearApply(userID)
+				bt.setState(userID, StateMainMenu)
+				return c.Edit("Организатор изменил анкету, пока вы её заполняли. Подайте заявку заново.", mainMenu())
+			} else if err != nil {
 				return c.Send("Ошибка: " + err.Error())
 			}
 			bt.clearApply(userID)
 			bt.setState(userID, StateMainMenu)
-			_ = c.Edit("Заявка отправлена! Администратор скоро её рассмотрит.")
+			bt.notifyApplication(app, admitted)
+			_ = c.Edit(bt.applicationSentText(app, admitted))
 			return c.Send("Выберите действие", mainMenu())
 		}
 
@@ -183,23 +373,61 @@ func registerHandlers(bt *Bot) {
 			}
 
 			tgID := domain.TelegramUserID(c.Sender().ID)
-			if t.OwnerID == tgID {
+			if t.RoleOf(tgID) != "" {
 				return tournamentMenu(c, t)
 			}
 			if t.UserParticipates(tgID) {
 				return participantMenu(c, t, tgID)
 			}
-
-			bt.setState(tgID, StateApplyEnterName)
 			app, err := bt.svc.GetApplication(t.ID, tgID)
 			if err != nil {
 				return c.Send("Ошибка: " + err.Error())
 			}
-			if app != nil {
-				bt.setState(tgID, StateMainMenu)
-				return c.Edit(fmt.Sprintf("Вы уже подали заявку на участие в турнире «%s». Ожидайте решения администратора.", t.Title))
+			return spectatorMenu(c, t, app)
+		}
+
+		if strings.HasPrefix(data, "spec_pairings_") {
+			tID, err := strconv.ParseInt(strings.TrimPrefix(data, "spec_pairings_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetTournament(domain.TournamentID(tID))
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))))
+			return c.Edit(pairingsText(t), menu)
+		}
+
+		if strings.HasPrefix(data, "spec_apply_") {
+			tID, err := strconv.ParseInt(strings.TrimPrefix(data, "spec_apply_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetTournament(domain.TournamentID(tID))
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			if t.UserParticipates(userID) {
+				return participantMenu(c, t, userID)
+			}
+			app, err := bt.svc.GetApplication(t.ID, userID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			if app != nil || !t.RegistrationOpen(time.Now()) {
+				return spectatorMenu(c, t, app)
+			}
+
+			bt.setState(userID, StateApplyEnterName)
+			bt.setApply(userID, &applyCtx{TournamentID: t.ID})
+			if t.TeamMode {
+				return c.Edit(fmt.Sprintf(
+					"Турнир «%s» командный. Вы подаёте заявку как капитан, игроков можно будет пригласить по ссылке.\n\nНапишите название команды:",
+					t.Title,
+				))
 			}
-			bt.setApply(tgID, &applyCtx{TournamentID: t.ID})
 			return c.Edit(fmt.Sprintf(
 				"Хотите участвовать в турнире «%s»?\n\nНапишите, пожалуйста, своё имя:",
 				t.Title,
@@ -213,14 +441,101 @@ func registerHandlers(bt *Bot) {
 			if err != nil {
 				return c.Send("Некорректный ID турнира")
 			}
+			return bt.showApplications(c, tID)
+		}
+		if strings.HasPrefix(data, "app_view_") {
+			parts := strings.Split(strings.TrimPrefix(data, "app_view_"), "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			tgID64, err2 := strconv.ParseInt(parts[1], 10, 64)
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректный формат ID")
+			}
+			tID := domain.TournamentID(tID64)
 
-			apps, err := bt.svc.GetApplications(tID)
+			t, err := bt.svc.GetManagedTournament(tID, userID, domain.PermApplications)
 			if err != nil {
 				return c.Send("Ошибка: " + err.Error())
 			}
-			t, _ := bt.svc.GetTournament(tID)
+			apps, err := bt.svc.GetApplications(tID, userID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			for _, app := range apps {
+				if app.TelegramUserID == domain.TelegramUserID(tgID64) {
+					return applicationCard(c, t, apps, app)
+				}
+			}
+			_ = c.Send("Заявка уже рассмотрена.")
+			return bt.showApplications(c, tID)
+		}
+		if strings.HasPrefix(data, "apps_") {
+			action, rest, _ := strings.Cut(strings.TrimPrefix(data, "apps_"), "_")
+			parts := strings.Split(rest, "_")
+			tID64, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			tID := domain.TournamentID(tID64)
 
-			applicationMenu(c, t, apps)
+			switch action {
+			case "page", "sel":
+				if len(parts) != 2 {
+					return c.Send("Некорректные данные кнопки")
+				}
+				n, err := strconv.ParseInt(parts[1], 10, 64)
+				if err != nil {
+					return c.Send("Некорректные данные кнопки")
+				}
+				bt.updateReview(userID, tID, func(rc *reviewCtx) {
+					if action == "page" {
+						rc.Page = int(n)
+					} else if id := domain.TelegramUserID(n); rc.Selected[id] {
+						delete(rc.Selected, id)
+					} else {
+						rc.Selected[id] = true
+					}
+				})
+			case "sort":
+				bt.updateReview(userID, tID, func(rc *reviewCtx) {
+					rc.Filter.NewestFirst = !rc.Filter.NewestFirst
+					rc.Page = 0
+				})
+			case "field":
+				t, err := bt.svc.GetManagedTournament(tID, userID, domain.PermApplications)
+				if err != nil {
+					return c.Send("Ошибка: " + err.Error())
+				}
+				bt.updateReview(userID, tID, func(rc *reviewCtx) {
+					rc.Filter.AnsweredField = nextAnsweredField(t, rc.Filter.AnsweredField)
+					rc.Page = 0
+				})
+			case "clear":
+				bt.updateReview(userID, tID, func(rc *reviewCtx) {
+					rc.Selected = make(map[domain.TelegramUserID]bool)
+				})
+			case "all":
+				menu := &tb.ReplyMarkup{}
+				btnYes := menu.Data("Да, принять", fmt.Sprintf("apps_allok_%d", tID))
+				btnNo := menu.Data("Отмена", fmt.Sprintf("applications_tournament%d", tID))
+				menu.Inline(menu.Row(btnYes, btnNo))
+				return c.Edit("Принять все заявки, которые сейчас в списке? Заявки из листа ожидания не затрагиваются.", menu)
+			case "allok":
+				return bt.approveApplications(c, tID, true)
+			case "approve":
+				return bt.approveApplications(c, tID, false)
+			case "reject":
+				menu := &tb.ReplyMarkup{}
+				btnYes := menu.Data("Да, отклонить", fmt.Sprintf("apps_rejectok_%d", tID))
+				btnNo := menu.Data("Отмена", fmt.Sprintf("applications_tournament%d", tID))
+				menu.Inline(menu.Row(btnYes, btnNo))
+				return c.Edit(fmt.Sprintf("Отклонить выбранные заявки (%d)? Участники получат уведомление.", len(bt.reviewOf(userID, tID).Selected)), menu)
+			case "rejectok":
+				return bt.rejectApplications(c, tID)
+			}
+			return bt.showApplications(c, tID)
 		}
 
 		if strings.HasPrefix(data, "start_tournament") {
@@ -230,11 +545,15 @@ func registerHandlers(bt *Bot) {
 				return c.Send("Некорректный ID турнира")
 			}
 			tID := domain.TournamentID(tID64)
-			err = bt.svc.StartTournament(tID)
+			err = bt.svc.StartTournament(tID, userID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			t, err := bt.svc.GetTournament(tID)
 			if err != nil {
 				return c.Send("Ошибка: " + err.Error())
 			}
-			return c.Send(fmt.Sprintf("Турнир ID %d начат!", tID))
+			return c.Send(fmt.Sprintf("Турнир ID %d начат!\n\n🎲 Зерно жеребьёвки: %d\nSHA-256: %s", tID, t.DrawSeed, t.DrawSeedHash()))
 		}
 
 		if strings.HasPrefix(data, "information_tournament") {
@@ -271,154 +590,966 @@ func registerHandlers(bt *Bot) {
 			return c.Edit(info, menu)
 		}
 
-		if strings.HasPrefix(data, "pmatches_tournament") {
-			rest := strings.TrimPrefix(data, "pmatches_tournament")
-			parts := strings.Split(rest, "_")
-			if len(parts) != 2 {
-				return c.Send("Некорректные данные кнопки")
-			}
-			tID64, err1 := strconv.Atoi(parts[0])
-			tgID64, err2 := strconv.Atoi(parts[1])
-			if err1 != nil || err2 != nil {
-				return c.Send("Некорректный формат ID")
+		if strings.HasPrefix(data, "about_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "about_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
 			}
-			tID := domain.TournamentID(tID64)
-			tgID := domain.TelegramUserID(tgID64)
-
-			t, err := bt.svc.GetTournament(tID)
+			t, err := bt.svc.GetTournament(domain.TournamentID(tID64))
 			if err != nil {
 				return c.Send("Ошибка: " + err.Error())
 			}
+			return aboutMenu(c, t, bt.userLocation(userID))
+		}
 
-			p := t.FindParticipantBytgID(tgID)
-			text := t.GetMatchesHistory(p.ID)
-
-			menu := &tb.ReplyMarkup{}
-			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
-
-			matches := t.GetParticipantMatches(p.ID)
-			if m := matches[len(matches)-1]; m != nil {
-				winRes, loseRes := "p1", "p2"
-				if m.P1 != p.ID {
-					winRes, loseRes = "p2", "p1"
-				}
-				btnWin := menu.Data("✅ Я выиграл", fmt.Sprintf("pmatch_report_%d_%d_%s", t.ID, m.ID, winRes))
-				btnDraw := menu.Data("🤝 Ничья", fmt.Sprintf("pmatch_report_%d_%d_draw", t.ID, m.ID))
-				btnLose := menu.Data("❌ Я проиграл", fmt.Sprintf("pmatch_report_%d_%d_%s", t.ID, m.ID, loseRes))
-				menu.Inline(menu.Row(btnWin, btnDraw, btnLose), menu.Row(btnBack))
-			} else {
-				menu.Inline(menu.Row(btnBack))
+		if strings.HasPrefix(data, "profile_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "profile_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
 			}
-			if err := c.Edit(text, menu); err != nil {
-				return c.Send(text, menu)
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
 			}
-			return c.Edit(text, menu)
+			return profileMenu(c, t, bt.userLocation(userID))
 		}
-
-		if strings.HasPrefix(data, "pmatch_report_") {
-			rest := strings.TrimPrefix(data, "pmatch_report_")
-			parts := strings.SplitN(rest, "_", 3)
-			if len(parts) != 3 {
+		if strings.HasPrefix(data, "profile_edit_") {
+			id, field, ok := strings.Cut(strings.TrimPrefix(data, "profile_edit_"), "_")
+			tID64, err := strconv.ParseInt(id, 10, 64)
+			if !ok || err != nil {
 				return c.Send("Некорректные данные кнопки")
 			}
-			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
-			mID64, err2 := strconv.ParseInt(parts[1], 10, 64)
-			res := parts[2] // "p1" | "p2" | "draw"
-			if err1 != nil || err2 != nil || (res != "p1" && res != "p2" && res != "draw") {
-				return c.Send("Некорректные данные результата")
-			}
-
 			tID := domain.TournamentID(tID64)
-			mID := domain.MatchID(mID64)
-			uid := domain.TelegramUserID(c.Sender().ID)
-
-			t, err := bt.svc.ReportMatchResult(tID, mID, uid, domain.ResultType(res))
-			if err != nil {
-				return c.Send("Ошибка при отправке результата: " + err.Error())
+			if _, err := bt.svc.GetManagedTournament(tID, userID, domain.PermSettings); err != nil {
+				return c.Send("Ошибка: " + err.Error())
 			}
-
-			_ = c.Send("✅ Ваш отчёт о матче принят.")
-
-			p := t.FindParticipantBytgID(uid)
-			text := t.GetMatchesHistory(p.ID)
+			bt.setState(userID, StateAdminAwaitProfileField)
+			bt.setAdminCtx(userID, &adminSetResultCtx{TournamentID: tID, ProfileField: domain.ProfileField(field)})
 
 			menu := &tb.ReplyMarkup{}
-			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
-			menu.Inline(menu.Row(btnBack))
-
-			if err := c.Edit(text, menu); err != nil {
-				return c.Send(text, menu)
-			}
-			return nil
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("profile_tournament%d", tID))))
+			return c.Edit(fmt.Sprintf(
+				"%s: отправьте новый текст, не длиннее %d символов. Чтобы очистить поле, отправьте «-».",
+				profileFieldName(domain.ProfileField(field)), domain.MaxProfileLength,
+			), menu)
 		}
-		if strings.HasPrefix(data, "adm_matches_tournament") {
-			tIDStr := strings.TrimPrefix(data, "adm_matches_tournament")
-			tID64, err := strconv.ParseInt(tIDStr, 10, 64)
+		if strings.HasPrefix(data, "profile_start_") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "profile_start_"), 10, 64)
 			if err != nil {
 				return c.Send("Некорректный ID турнира")
 			}
 			tID := domain.TournamentID(tID64)
-
-			t, err := bt.svc.GetTournament(tID)
-			if err != nil {
+			if _, err := bt.svc.GetManagedTournament(tID, userID, domain.PermSettings); err != nil {
 				return c.Send("Ошибка: " + err.Error())
 			}
+			bt.setState(userID, StateAdminAwaitStartDate)
+			bt.setAdminCtx(userID, &adminSetResultCtx{TournamentID: tID})
 
-			text := buildCurrentMatchesText(t)
 			menu := &tb.ReplyMarkup{}
-			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
-			btnSet := menu.Data("✏️ Изменить результат", fmt.Sprintf("adm_setresult_%d", t.ID))
-			menu.Inline(menu.Row(btnSet), menu.Row(btnBack))
-			return c.Edit(text, menu)
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("profile_tournament%d", tID))))
+			return c.Edit("Введите дату и время начала турнира в формате ДД.ММ.ГГГГ ЧЧ:ММ.\n\nНапример: 25.05.2025 18:00", menu)
 		}
-
-		if strings.HasPrefix(data, "adm_setresult_") {
-			tIDStr := strings.TrimPrefix(data, "adm_setresult_")
-			tID64, err := strconv.ParseInt(tIDStr, 10, 64)
+		if strings.HasPrefix(data, "profile_nostart_") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "profile_nostart_"), 10, 64)
 			if err != nil {
 				return c.Send("Некорректный ID турнира")
 			}
-			tID := domain.TournamentID(tID64)
-
-			adminID := domain.TelegramUserID(c.Sender().ID)
-			bt.setState(adminID, StateAdminAwaitMatchID)
-			bt.setAdminCtx(adminID, &adminSetResultCtx{TournamentID: tID})
-
-			menu := &tb.ReplyMarkup{}
-			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("adm_matches_tournament%d", tID))
-			menu.Inline(menu.Row(btnBack))
-			return c.Edit("Введите ID матча (число):", menu)
+			t, err := bt.svc.SetStartDate(domain.TournamentID(tID64), userID, nil)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return profileMenu(c, t, bt.userLocation(userID))
 		}
 
-		if strings.HasPrefix(data, "adm_apply_result_") {
-			rest := strings.TrimPrefix(data, "adm_apply_result_")
-			parts := strings.SplitN(rest, "_", 3)
-			if len(parts) != 3 {
-				return c.Send("Некорректные данные кнопки")
+		if strings.HasPrefix(data, "tiebreaks_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "tiebreaks_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
 			}
-
-			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
-			mID64, err2 := strconv.ParseInt(parts[1], 10, 64)
-			res := parts[2]
-			if err1 != nil || err2 != nil || (res != "p1" && res != "p2" && res != "draw") {
-				return c.Send("Некорректные данные результата")
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
 			}
-			tID := domain.TournamentID(tID64)
-			mID := domain.MatchID(mID64)
-			adminID := domain.TelegramUserID(c.Sender().ID)
+			return tieBreaksMenu(c, t)
+		}
 
-			t, err := bt.svc.SetMatchResultByAdmin(tID, mID, adminID, domain.ResultType(res))
+		if strings.HasPrefix(data, "tiebreak_add_") || strings.HasPrefix(data, "tiebreak_reset_") {
+			var rest string
+			reset := strings.HasPrefix(data, "tiebreak_reset_")
+			if reset {
+				rest = strings.TrimPrefix(data, "tiebreak_reset_")
+			} else {
+				rest = strings.TrimPrefix(data, "tiebreak_add_")
+			}
+			parts := strings.SplitN(rest, "_", 2)
+			tID64, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
 			if err != nil {
 				return c.Send("Ошибка: " + err.Error())
 			}
 
-			_ = c.Send("✅ Результат матча обновлён администратором.")
+			var tieBreaks []domain.TieBreak
+			if !reset {
+				if len(parts) != 2 {
+					return c.Send("Некорректные данные кнопки")
+				}
+				tieBreaks = append(t.TieBreaks, domain.TieBreak(parts[1]))
+			}
+			t, err = bt.svc.SetTieBreaks(t.ID, userID, tieBreaks)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return tieBreaksMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "scoring_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "scoring_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return scoringMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "scoring_preset_") {
+			parts := strings.SplitN(strings.TrimPrefix(data, "scoring_preset_"), "_", 2)
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			rules, ok := scoringPresets[parts[1]]
+			if !ok {
+				return c.Send("Неизвестная система очков")
+			}
+			t, err := bt.svc.SetScoringRules(domain.TournamentID(tID64), userID, rules)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return scoringMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "scoring_custom_") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "scoring_custom_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			tID := domain.TournamentID(tID64)
+			if _, err := bt.svc.GetManagedTournament(tID, userID, domain.PermSettings); err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateAdminAwaitScoring)
+			bt.setAdminCtx(userID, &adminSetResultCtx{TournamentID: tID})
+
+			menu := &tb.ReplyMarkup{}
+			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("scoring_tournament%d", tID))
+			menu.Inline(menu.Row(btnBack))
+			return c.Edit("Введите шесть чисел через пробел: очки за победу, ничью, поражение, "+
+				"свободный от игры раунд, техническую победу и техническое поражение.\n\nНапример: 3 1 0 3 3 0", menu)
+		}
+
+		if strings.HasPrefix(data, "bestof_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "bestof_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return bestOfMenu(c, t)
+		}
+		if strings.HasPrefix(data, "bestof_set_") {
+			parts := strings.Split(strings.TrimPrefix(data, "bestof_set_"), "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			bestOf, err2 := strconv.Atoi(parts[1])
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректные данные кнопки")
+			}
+			t, err := bt.svc.SetBestOf(domain.TournamentID(tID64), userID, 0, bestOf)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return bestOfMenu(c, t)
+		}
+		if strings.HasPrefix(data, "bestof_round_") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "bestof_round_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			tID := domain.TournamentID(tID64)
+			if _, err := bt.svc.GetManagedTournament(tID, userID, domain.PermSettings); err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateAdminAwaitBestOf)
+			bt.setAdminCtx(userID, &adminSetResultCtx{TournamentID: tID})
+
+			menu := &tb.ReplyMarkup{}
+			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("bestof_tournament%d", tID))
+			menu.Inline(menu.Row(btnBack))
+			return c.Edit("Введите номер раунда и число игр в матче через пробел.\n\nНапример, финал до трёх побед в 5 раунде: 5 5", menu)
+		}
+
+		if strings.HasPrefix(data, "withdraw_ask_") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "withdraw_ask_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetTournament(domain.TournamentID(tID64))
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			menu := &tb.ReplyMarkup{}
+			btnYes := menu.Data("Да, сняться", fmt.Sprintf("withdraw_ok_%d", tID64))
+			btnNo := menu.Data("Отмена", fmt.Sprintf("tournament_%d", tID64))
+			menu.Inline(menu.Row(btnYes, btnNo))
+			if t.Status.Preparing() {
+				return c.Edit("Отказаться от участия? Ваше место займёт следующий из листа ожидания.", menu)
+			}
+			return c.Edit("Сняться с турнира? Текущий матч будет засчитан как техническое поражение, вернуться будет нельзя.", menu)
+		}
+		if strings.HasPrefix(data, "withdraw_ok_") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "withdraw_ok_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.WithdrawFromTournament(domain.TournamentID(tID64), userID)
+			if errors.Is(err, domain.ErrNoLegalPairing) {
+				_ = c.Send("⚠️ Следующий раунд невозможно составить без повторных встреч. Организатор разберётся.")
+			} else if errors.Is(err, domain.ErrNotParticipant) {
+				return c.Send("Вы не участвуете в этом турнире.")
+			} else if errors.Is(err, domain.ErrCaptainWithdrawsTeam) {
+				return c.Send("Снять команду с турнира может только капитан.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return c.Edit(fmt.Sprintf("Вы снялись с турнира «%s».", t.Title), mainMenu())
+		}
+		if strings.HasPrefix(data, "remove_pick_") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "remove_pick_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermParticipants)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return removeParticipantMenu(c, t)
+		}
+		if strings.HasPrefix(data, "remove_ask_") || strings.HasPrefix(data, "remove_ok_") {
+			confirmed := strings.HasPrefix(data, "remove_ok_")
+			rest := strings.TrimPrefix(strings.TrimPrefix(data, "remove_ask_"), "remove_ok_")
+			parts := strings.Split(rest, "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			pID64, err2 := strconv.ParseInt(parts[1], 10, 64)
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректный формат ID")
+			}
+			tID, pID := domain.TournamentID(tID64), domain.ParticipantID(pID64)
+
+			if !confirmed {
+				t, err := bt.svc.GetManagedTournament(tID, userID, domain.PermParticipants)
+				if err != nil {
+					return c.Send("Ошибка: " + err.Error())
+				}
+				p := t.FindParticipantByPID(pID)
+				if p == nil {
+					return c.Send("Участник не найден")
+				}
+				menu := &tb.ReplyMarkup{}
+				btnYes := menu.Data("Да, снять", fmt.Sprintf("remove_ok_%d_%d", tID, pID))
+				btnNo := menu.Data("Отмена", fmt.Sprintf("remove_pick_%d", tID))
+				menu.Inline(menu.Row(btnYes, btnNo))
+				return c.Edit(fmt.Sprintf("Снять участника %s с турнира?", p.Name), menu)
+			}
+
+			t, err := bt.svc.GetManagedTournament(tID, userID, domain.PermParticipants)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			p := t.FindParticipantByPID(pID)
+			if p == nil {
+				return c.Send("Участник не найден")
+			}
+			// before the start the participant is deleted, so the name is taken first
+			name := p.Name
+
+			t, err = bt.svc.RemoveParticipant(tID, userID, pID)
+			if errors.Is(err, domain.ErrNoLegalPairing) {
+				_ = c.Send("⚠️ Следующий раунд невозможно составить: все оставшиеся пары уже играли друг с другом.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			_ = c.Send(fmt.Sprintf("Участник %s снят с турнира.", name))
+			return removeParticipantMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "deadline_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "deadline_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return deadlineMenu(c, t, bt.userLocation(userID))
+		}
+		if strings.HasPrefix(data, "deadline_quick_") {
+			parts := strings.Split(strings.TrimPrefix(data, "deadline_quick_"), "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			hours, err2 := strconv.Atoi(parts[1])
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректные данные кнопки")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			t, err = bt.svc.SetDeadline(t.ID, userID, t.CurrentRound, time.Now().Add(time.Duration(hours)*time.Hour))
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return deadlineMenu(c, t, bt.userLocation(userID))
+		}
+		if strings.HasPrefix(data, "deadline_policy_") {
+			parts := strings.SplitN(strings.TrimPrefix(data, "deadline_policy_"), "_", 2)
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.SetDeadlinePolicy(domain.TournamentID(tID64), userID, domain.DeadlinePolicy(parts[1]))
+			if errors.Is(err, domain.ErrUnknownDeadlinePolicy) {
+				return c.Send("Ошибка: неизвестное правило для истёкших дедлайнов")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return deadlineMenu(c, t, bt.userLocation(userID))
+		}
+		if strings.HasPrefix(data, "deadline_custom_") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "deadline_custom_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			tID := domain.TournamentID(tID64)
+			if _, err := bt.svc.GetManagedTournament(tID, userID, domain.PermSettings); err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateAdminAwaitDeadline)
+			bt.setAdminCtx(userID, &adminSetResultCtx{TournamentID: tID})
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("deadline_tournament%d", tID))))
+			return c.Edit("Введите номер раунда и дедлайн в формате ДД.ММ.ГГГГ ЧЧ:ММ.\n\nНапример: 2 25.05.2025 18:00", menu)
+		}
+
+		if strings.HasPrefix(data, "reg_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "reg_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return registrationMenu(c, t, bt.userLocation(userID))
+		}
+		if strings.HasPrefix(data, "reg_auto_") {
+			id, on, ok := strings.Cut(strings.TrimPrefix(data, "reg_auto_"), "_")
+			tID64, err := strconv.ParseInt(id, 10, 64)
+			if !ok || err != nil {
+				return c.Send("Некорректные данные кнопки")
+			}
+			t, err := bt.svc.SetAutoApprove(domain.TournamentID(tID64), userID, on == "true")
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return registrationMenu(c, t, bt.userLocation(userID))
+		}
+		if strings.HasPrefix(data, "reg_nodeadline_") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "reg_nodeadline_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.SetRegistrationDeadline(domain.TournamentID(tID64), userID, nil)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return registrationMenu(c, t, bt.userLocation(userID))
+		}
+		if strings.HasPrefix(data, "reg_capacity_") || strings.HasPrefix(data, "reg_deadline_") {
+			state, prompt := StateAdminAwaitCapacity, "Введите наибольшее число участников. 0 — без лимита."
+			if strings.HasPrefix(data, "reg_deadline_") {
+				state, prompt = StateAdminAwaitRegDeadline, "Введите, до какого момента принимать заявки, в формате ДД.ММ.ГГГГ ЧЧ:ММ.\n\nНапример: 25.05.2025 18:00"
+			}
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimPrefix(data, "reg_capacity_"), "reg_deadline_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			tID := domain.TournamentID(tID64)
+			if _, err := bt.svc.GetManagedTournament(tID, userID, domain.PermSettings); err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, state)
+			bt.setAdminCtx(userID, &adminSetResultCtx{TournamentID: tID})
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("reg_tournament%d", tID))))
+			return c.Edit(prompt, menu)
+		}
+
+		if strings.HasPrefix(data, "form_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "form_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return formMenu(c, t)
+		}
+		if strings.HasPrefix(data, "form_add_") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "form_add_"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return c.Edit("Какой ответ ожидается на вопрос?", fieldKindMenu(t.ID))
+		}
+		if strings.HasPrefix(data, "form_kind_") {
+			id, kind, ok := strings.Cut(strings.TrimPrefix(data, "form_kind_"), "_")
+			tID64, err := strconv.ParseInt(id, 10, 64)
+			if !ok || err != nil {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID := domain.TournamentID(tID64)
+			if _, err := bt.svc.GetManagedTournament(tID, userID, domain.PermSettings); err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateAdminAwaitFormQuestion)
+			bt.setAdminCtx(userID, &adminSetResultCtx{TournamentID: tID, FieldKind: domain.FieldKind(kind)})
+
+			prompt := "Напишите вопрос."
+			if domain.FieldKind(kind) == domain.FieldChoice {
+				prompt = "Напишите вопрос в первой строке, а варианты ответа — в следующих, по одному на строку."
+			}
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("form_tournament%d", tID))))
+			return c.Edit(prompt, menu)
+		}
+		if strings.HasPrefix(data, "form_req_") || strings.HasPrefix(data, "form_del_") {
+			parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(data, "form_req_"), "form_del_"), "_")
+			if len(parts) < 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			fID, err2 := strconv.Atoi(parts[1])
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID := domain.TournamentID(tID64)
+
+			var t *domain.Tournament
+			var err error
+			if strings.HasPrefix(data, "form_del_") {
+				t, err = bt.svc.RemoveFormField(tID, userID, fID)
+			} else {
+				t, err = bt.svc.SetFormFieldRequired(tID, userID, fID, len(parts) == 3 && parts[2] == "true")
+			}
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return formMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "teams_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "teams_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return teamsMenu(c, t)
+		}
+		if strings.HasPrefix(data, "teams_set_") {
+			parts := strings.Split(strings.TrimPrefix(data, "teams_set_"), "_")
+			if len(parts) != 3 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			teamMode, err2 := strconv.ParseBool(parts[1])
+			rosterReports, err3 := strconv.ParseBool(parts[2])
+			if err1 != nil || err2 != nil || err3 != nil {
+				return c.Send("Некорректные данные кнопки")
+			}
+			t, err := bt.svc.SetTeamMode(domain.TournamentID(tID64), userID, teamMode, rosterReports)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return teamsMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "seeding_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "seeding_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermSettings)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return seedingMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "seeding_method_") {
+			parts := strings.SplitN(strings.TrimPrefix(data, "seeding_method_"), "_", 2)
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.SetSeeding(domain.TournamentID(tID64), userID, domain.SeedingMethod(parts[1]))
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return seedingMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "seeding_order_") || strings.HasPrefix(data, "seeding_ratings_") {
+			state, prompt := StateAdminAwaitSeedOrder, "Введите номера участников через пробел в порядке посева, начиная с сильнейшего. "+
+				"Не указанные участники встанут в конец по порядку регистрации."
+			rest := strings.TrimPrefix(data, "seeding_order_")
+			if strings.HasPrefix(data, "seeding_ratings_") {
+				state, prompt = StateAdminAwaitRatings, "Введите рейтинги, по одному участнику в строке: «номер рейтинг».\n\nНапример:\n0 1850\n3 1620"
+				rest = strings.TrimPrefix(data, "seeding_ratings_")
+			}
+			tID64, err := strconv.ParseInt(rest, 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			tID := domain.TournamentID(tID64)
+			if _, err := bt.svc.GetManagedTournament(tID, userID, domain.PermSettings); err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, state)
+			bt.setAdminCtx(userID, &adminSetResultCtx{TournamentID: tID})
+
+			menu := &tb.ReplyMarkup{}
+			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("seeding_tournament%d", tID))
+			menu.Inline(menu.Row(btnBack))
+			return c.Edit(prompt, menu)
+		}
+
+		if strings.HasPrefix(data, "pmatches_tournament") {
+			rest := strings.TrimPrefix(data, "pmatches_tournament")
+			parts := strings.Split(rest, "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.Atoi(parts[0])
+			tgID64, err2 := strconv.Atoi(parts[1])
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректный формат ID")
+			}
+			tID := domain.TournamentID(tID64)
+			tgID := domain.TelegramUserID(tgID64)
+
+			t, err := bt.svc.GetTournament(tID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+
+			p := t.FindParticipantBytgID(tgID)
+			if p == nil {
+				return c.Send("Участник не найден в этом турнире.")
+			}
+			text := t.GetMatchesHistory(p.ID)
+			if d := t.Deadlines[t.CurrentRound]; d != nil && !d.Expired {
+				text = fmt.Sprintf("⏰ Дедлайн раунда %d: %s\n\n", t.CurrentRound, formatTime(d.At, bt.userLocation(userID))) + text
+			}
+
+			menu := &tb.ReplyMarkup{}
+			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
+
+			m := t.FindCurrentMatch(p.ID)
+			if m != nil && m.State != domain.MatchCompleted {
+				text = scheduleText(m, p, bt.userLocation(tgID)) + text
+			}
+			if m != nil && m.State != domain.MatchCompleted && !t.CanReport(p, tgID) {
+				text += "Результат матча сообщает капитан команды."
+				menu.Inline(menu.Row(btnBack))
+			} else if m != nil && m.State != domain.MatchCompleted {
+				btnScore := menu.Data("📝 Сообщить счёт", fmt.Sprintf("pmatch_score_%d_%d", t.ID, m.ID))
+				menu.Inline(menu.Row(btnScore), scheduleRow(menu, t, m, p), menu.Row(btnBack))
+			} else {
+				menu.Inline(menu.Row(btnBack))
+			}
+			if err := c.Edit(text, menu); err != nil {
+				return c.Send(text, menu)
+			}
+			return c.Edit(text, menu)
+		}
+
+		if strings.HasPrefix(data, "pmatch_score_") {
+			parts := strings.Split(strings.TrimPrefix(data, "pmatch_score_"), "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			mID64, err2 := strconv.ParseInt(parts[1], 10, 64)
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректный формат ID")
+			}
+			tID := domain.TournamentID(tID64)
+
+			t, err := bt.svc.GetTournament(tID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StatePlayerAwaitScore)
+			bt.setReportCtx(userID, &reportCtx{TournamentID: tID, MatchID: domain.MatchID(mID64)})
+
+			prompt := "Введите счёт матча, начиная со своего, например 3:1."
+			if bestOf := t.BestOfRound(t.CurrentRound); bestOf > 1 {
+				prompt = fmt.Sprintf("Матч играется %s. Введите счёт каждой партии через пробел, начиная со своего, например 11:9 8:11 11:5.", bestOfName(bestOf))
+			}
+			menu := &tb.ReplyMarkup{}
+			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("pmatches_tournament%d_%d", tID, userID))
+			menu.Inline(menu.Row(btnBack))
+			return c.Edit(prompt, menu)
+		}
+
+		if strings.HasPrefix(data, "status_ask_") || strings.HasPrefix(data, "status_set_") {
+			confirmed := strings.HasPrefix(data, "status_set_")
+			rest := strings.TrimPrefix(strings.TrimPrefix(data, "status_ask_"), "status_set_")
+			id, status, ok := strings.Cut(rest, "_")
+			tID64, err := strconv.ParseInt(id, 10, 64)
+			if !ok || err != nil {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID := domain.TournamentID(tID64)
+
+			if !confirmed {
+				t, err := bt.svc.GetManagedTournament(tID, userID, domain.PermSettings)
+				if err != nil {
+					return c.Send("Ошибка: " + err.Error())
+				}
+				menu := &tb.ReplyMarkup{}
+				btnYes := menu.Data("Да", fmt.Sprintf("status_set_%d_%s", tID, status))
+				btnNo := menu.Data("Нет", fmt.Sprintf("tournament_%d", tID))
+				menu.Inline(menu.Row(btnYes, btnNo))
+				return c.Edit(statusConfirmText(t, domain.Status(status)), menu)
+			}
+
+			t, err := bt.svc.SetStatus(tID, userID, domain.Status(status))
+			if errors.Is(err, domain.ErrInvalidTransition) {
+				return c.Send("Сейчас турнир нельзя перевести в этот статус.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			if t.Status == domain.StatusArchived {
+				return c.Edit(fmt.Sprintf("Турнир «%s» убран в архив.", t.Title), mainMenu())
+			}
+			return tournamentMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "staff_tournament") {
+			tID64, err := strconv.ParseInt(strings.TrimPrefix(data, "staff_tournament"), 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			t, err := bt.svc.GetManagedTournament(domain.TournamentID(tID64), userID, domain.PermStaff)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return staffMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "staff_invite_") {
+			parts := strings.Split(strings.TrimPrefix(data, "staff_invite_"), "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err := strconv.ParseInt(parts[0], 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			role := domain.Role(parts[1])
+
+			code, err := bt.svc.CreateRoleInvite(domain.TournamentID(tID64), userID, role)
+			if errors.Is(err, domain.ErrUnknownRole) {
+				return c.Send("Ошибка: неизвестная роль")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return c.Send(fmt.Sprintf("Ссылка для приглашения (%s), работает один раз:\nhttps://t.me/%s?start=staff_%s",
+				roleName(role), bt.bot.Me.Username, code))
+		}
+
+		if strings.HasPrefix(data, "staff_remove_") {
+			parts := strings.Split(strings.TrimPrefix(data, "staff_remove_"), "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			uid64, err2 := strconv.ParseInt(parts[1], 10, 64)
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректный формат ID")
+			}
+			tID := domain.TournamentID(tID64)
+
+			if err := bt.svc.RemoveStaffMember(tID, userID, domain.TelegramUserID(uid64)); err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			t, err := bt.svc.GetTournament(tID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.notify(domain.TelegramUserID(uid64), fmt.Sprintf("Вы больше не в составе организаторов турнира «%s».", t.Title))
+			return staffMenu(c, t)
+		}
+
+		if strings.HasPrefix(data, "tz_set_") {
+			tz := strings.TrimPrefix(data, "tz_set_")
+			if err := bt.svc.SetUserTimezone(userID, tz); err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			return c.Edit("✅ Часовой пояс: "+tz, mainMenu())
+		}
+
+		if strings.HasPrefix(data, "sched_propose_") || strings.HasPrefix(data, "sched_accept_") {
+			accept := strings.HasPrefix(data, "sched_accept_")
+			rest := strings.TrimPrefix(strings.TrimPrefix(data, "sched_propose_"), "sched_accept_")
+			parts := strings.Split(rest, "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			mID64, err2 := strconv.ParseInt(parts[1], 10, 64)
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректный формат ID")
+			}
+			tID := domain.TournamentID(tID64)
+			mID := domain.MatchID(mID64)
+
+			if accept {
+				t, err := bt.svc.AcceptMatchTime(tID, mID, userID)
+				if errors.Is(err, domain.ErrNoProposal) {
+					return c.Send("Соперник уже отозвал предложение.")
+				} else if err != nil {
+					return c.Send("Ошибка: " + err.Error())
+				}
+				bt.notifyScheduled(t, t.FindMatch(mID))
+				return nil
+			}
+
+			bt.setState(userID, StatePlayerAwaitMatchTime)
+			bt.setReportCtx(userID, &reportCtx{TournamentID: tID, MatchID: mID})
+
+			menu := &tb.ReplyMarkup{}
+			btnTz := menu.Data("🌍 Часовой пояс", TimezoneMenu)
+			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("pmatches_tournament%d_%d", tID, userID))
+			menu.Inline(menu.Row(btnTz), menu.Row(btnBack))
+			return c.Send(fmt.Sprintf("Введите дату и время матча, например 25.05.2025 18:00.\nЧасовой пояс: %s", bt.userLocation(userID)), menu)
+		}
+
+		if strings.HasPrefix(data, "adm_matches_tournament") {
+			tIDStr := strings.TrimPrefix(data, "adm_matches_tournament")
+			tID64, err := strconv.ParseInt(tIDStr, 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			tID := domain.TournamentID(tID64)
+
+			t, err := bt.svc.GetManagedTournament(tID, userID, domain.PermResults)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+
+			text := buildCurrentMatchesText(t)
+			menu := currentMatchesMenu(t)
+			return c.Edit(text, menu)
+		}
+
+		if strings.HasPrefix(data, "conflict_view_") || strings.HasPrefix(data, "conflict_photos_") {
+			photos := strings.HasPrefix(data, "conflict_photos_")
+			rest := strings.TrimPrefix(strings.TrimPrefix(data, "conflict_view_"), "conflict_photos_")
+			parts := strings.Split(rest, "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			mID64, err2 := strconv.ParseInt(parts[1], 10, 64)
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректный формат ID")
+			}
+			tID := domain.TournamentID(tID64)
+			mID := domain.MatchID(mID64)
+
+			t, err := bt.svc.GetManagedTournament(tID, userID, domain.PermResults)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			m := t.FindMatch(mID)
+			if m == nil {
+				return c.Send("Матч не найден")
+			}
+			evidence, err := bt.svc.GetEvidence(tID, mID, userID)
+			if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+
+			if photos {
+				for _, e := range evidence {
+					if e.PhotoFileID != "" {
+						_ = c.Send(&tb.Photo{File: tb.File{FileID: e.PhotoFileID}, Caption: evidenceAuthor(t, e) + " " + e.Text})
+					}
+				}
+				return nil
+			}
+			if m.State != domain.MatchConflicted {
+				menu := currentMatchesMenu(t)
+				return c.Edit(fmt.Sprintf("Спор по матчу #%d уже решён.\n\n%s", m.ID, buildCurrentMatchesText(t)), menu)
+			}
+			if err := c.Edit(conflictText(t, m, evidence), conflictMenu(t, m, countPhotos(evidence))); err != nil {
+				return c.Send(conflictText(t, m, evidence), conflictMenu(t, m, countPhotos(evidence)))
+			}
+			return nil
+		}
+
+		if strings.HasPrefix(data, "conflict_pick_") || strings.HasPrefix(data, "conflict_apply_") {
+			apply := strings.HasPrefix(data, "conflict_apply_")
+			rest := strings.TrimPrefix(strings.TrimPrefix(data, "conflict_pick_"), "conflict_apply_")
+			parts := strings.Split(rest, "_")
+			if len(parts) != 3 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			mID64, err2 := strconv.ParseInt(parts[1], 10, 64)
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректный формат ID")
+			}
+			tID := domain.TournamentID(tID64)
+			mID := domain.MatchID(mID64)
+			resolution := domain.Resolution(parts[2])
+
+			if apply {
+				return bt.resolveConflict(c, tID, mID, resolution, "")
+			}
+
+			if _, err := bt.svc.GetManagedTournament(tID, userID, domain.PermResults); err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateAdminAwaitResolution)
+			bt.setAdminCtx(userID, &adminSetResultCtx{TournamentID: tID, MatchID: mID, Resolution: resolution})
+
+			menu := &tb.ReplyMarkup{}
+			btnSkip := menu.Data("Без комментария", fmt.Sprintf("conflict_apply_%d_%d_%s", tID, mID, resolution))
+			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("conflict_view_%d_%d", tID, mID))
+			menu.Inline(menu.Row(btnSkip), menu.Row(btnBack))
+			return c.Edit("Напишите причину решения — её увидят оба участника.", menu)
+		}
+
+		if strings.HasPrefix(data, "evidence_") {
+			parts := strings.Split(strings.TrimPrefix(data, "evidence_"), "_")
+			if len(parts) != 2 {
+				return c.Send("Некорректные данные кнопки")
+			}
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			mID64, err2 := strconv.ParseInt(parts[1], 10, 64)
+			if err1 != nil || err2 != nil {
+				return c.Send("Некорректный формат ID")
+			}
+			bt.setState(userID, StatePlayerAwaitEvidence)
+			bt.setReportCtx(userID, &reportCtx{TournamentID: domain.TournamentID(tID64), MatchID: domain.MatchID(mID64)})
+			return c.Send("Отправьте комментарий или скриншот с подписью.")
+		}
+
+		if strings.HasPrefix(data, "adm_setresult_") {
+			tIDStr := strings.TrimPrefix(data, "adm_setresult_")
+			tID64, err := strconv.ParseInt(tIDStr, 10, 64)
+			if err != nil {
+				return c.Send("Некорректный ID турнира")
+			}
+			tID := domain.TournamentID(tID64)
+
+			adminID := domain.TelegramUserID(c.Sender().ID)
+			if _, err := bt.svc.GetManagedTournament(tID, adminID, domain.PermResults); err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(adminID, StateAdminAwaitMatchID)
+			bt.setAdminCtx(adminID, &adminSetResultCtx{TournamentID: tID})
+
+			menu := &tb.ReplyMarkup{}
+			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("adm_matches_tournament%d", tID))
+			menu.Inline(menu.Row(btnBack))
+			return c.Edit("Введите ID матча (число):", menu)
+		}
+
+		if strings.HasPrefix(data, "adm_apply_result_") {
+			rest := strings.TrimPrefix(data, "adm_apply_result_")
+			parts := strings.SplitN(rest, "_", 3)
+			if len(parts) != 3 {
+				return c.Send("Некорректные данные кнопки")
+			}
+
+			tID64, err1 := strconv.ParseInt(parts[0], 10, 64)
+			mID64, err2 := strconv.ParseInt(parts[1], 10, 64)
+			res, forfeit := strings.CutSuffix(parts[2], "ff")
+			if err1 != nil || err2 != nil || (res != "p1" && res != "p2" && res != "draw") {
+				return c.Send("Некорректные данные результата")
+			}
+			tID := domain.TournamentID(tID64)
+			mID := domain.MatchID(mID64)
+			adminID := domain.TelegramUserID(c.Sender().ID)
+			bt.setState(adminID, StateMainMenu)
+			bt.clearAdminCtx(adminID)
+
+			var t *domain.Tournament
+			var err error
+			if forfeit {
+				t, err = bt.svc.ForfeitMatchByAdmin(tID, mID, adminID, domain.ResultType(res))
+			} else {
+				t, err = bt.svc.SetMatchResultByAdmin(tID, mID, adminID, domain.ResultType(res))
+			}
+			if errors.Is(err, domain.ErrNoLegalPairing) {
+				_ = c.Send("⚠️ Следующий раунд невозможно составить: все оставшиеся пары уже играли друг с другом.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+
+			_ = c.Send("✅ Результат матча обновлён администратором.")
 
 			text := buildCurrentMatchesText(t)
-			menu := &tb.ReplyMarkup{}
-			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
-			btnSet := menu.Data("✏️ Изменить результат", fmt.Sprintf("adm_setresult_%d", t.ID))
-			menu.Inline(menu.Row(btnSet), menu.Row(btnBack))
+			menu := currentMatchesMenu(t)
 			if err := c.Edit(text, menu); err != nil {
 				return c.Send(text, menu)
 			}
@@ -428,6 +1559,21 @@ func registerHandlers(bt *Bot) {
 		return nil
 	})
 
+	bot.Handle(tb.OnPhoto, func(c tb.Context) error {
+		userID := domain.TelegramUserID(c.Sender().ID)
+		ctx := bt.getReportCtx(userID)
+		if bt.getState(userID) != StatePlayerAwaitEvidence || ctx == nil {
+			return c.Send("Не распознал команду")
+		}
+		return bt.addEvidence(c, &domain.Evidence{
+			TournamentID: ctx.TournamentID,
+			MatchID:      ctx.MatchID,
+			UserID:       userID,
+			Text:         c.Message().Caption,
+			PhotoFileID:  c.Message().Photo.FileID,
+		})
+	})
+
 	bot.Handle(tb.OnText, func(c tb.Context) error {
 		userID := domain.TelegramUserID(c.Sender().ID)
 		state := bt.getState(userID)
@@ -438,19 +1584,19 @@ func registerHandlers(bt *Bot) {
 			if strings.TrimSpace(title) == "" {
 				return c.Send("Название не может быть пустым. Попробуйте ещё раз.")
 			}
-			t, err := bt.svc.CreateTournament(userID, title)
-			if err != nil {
-				return c.Send("Ошибка: " + err.Error())
-			}
-			bt.setState(userID, StateTournamentManagement)
-			_ = c.Send(fmt.Sprintf("Турнир '%s' создан! ID: %d", t.Title, t.ID))
-
-			tournaments, err := bt.svc.GetUserTournaments(userID)
-			if err != nil {
-				return c.Send("Ошибка: " + err.Error())
+			bt.setCreateCtx(userID, &createCtx{Title: strings.TrimSpace(title)})
+			bt.setState(userID, StateWaitingTournamentGame)
+			return c.Send("Введите игру или дисциплину турнира. Рейтинг игроков ведётся отдельно для каждой.", gameMenu())
+		case StateWaitingTournamentGame:
+			game := strings.TrimSpace(c.Text())
+			cc := bt.getCreateCtx(userID)
+			if cc == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия сброшена. Выберите действие", mainMenu())
 			}
-
-			return sendTournamentsPage(c, tournaments, 0)
+			cc.Game = game
+			bt.setState(userID, StateWaitingTournamentSystem)
+			return c.Send("Выберите систему проведения турнира:", systemMenu())
 		case StateApplyEnterName:
 			name := strings.TrimSpace(c.Text())
 			if name == "" {
@@ -462,14 +1608,21 @@ func registerHandlers(bt *Bot) {
 				return c.Send("Сессия сброшена. Выберите действие", mainMenu())
 			}
 			ac.Name = name
-			bt.setState(userID, StateApplyEnterText)
-
-			menu := &tb.ReplyMarkup{}
-			btnSkip := menu.Data("Пропустить", ApplySkipText)
-			btnMain := menu.Data("Отменить", MainMenu)
-			menu.Inline(menu.Row(btnSkip, btnMain))
-
-			return c.Send("Напишите текст заявки, либо нажмите «Пропустить».", menu)
+			return bt.askNextField(c, ac)
+		case StateApplyEditName:
+			name := strings.TrimSpace(c.Text())
+			if name == "" {
+				return c.Send("Имя не может быть пустым. Введите ещё раз.")
+			}
+			return bt.editApplication(c, &name, nil)
+		case StateApplyEditText:
+			text := strings.TrimSpace(c.Text())
+			if text == "-" {
+				text = ""
+			}
+			return bt.editApplication(c, nil, &text)
+		case StateApplyFormField:
+			return bt.answerField(c, c.Text())
 
 		case StateApplyEnterText:
 			text := strings.TrimSpace(c.Text())
@@ -488,15 +1641,117 @@ func registerHandlers(bt *Bot) {
 				Name:           ac.Name,
 				TelegramTag:    &c.Sender().Username,
 				Text:           &text,
+				Answers:        ac.Answers,
 			}
-			if err := bt.svc.ApplyToTournament(app); err != nil {
+			admitted, err := bt.svc.ApplyToTournament(app)
+			if errors.Is(err, domain.ErrRegistrationShut) {
+				bt.clearApply(userID)
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Приём заявок на турнир уже закрыт.", mainMenu())
+			} else if errors.Is(err, domain.ErrAnswerRequired) || errors.Is(err, domain.ErrMustAgree) || errors.Is(err, domain.ErrInvalidAnswer) {
+				bt.clearApply(userID)
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Организатор изменил анкету, пока вы её заполняли. Подайте заявку заново.", mainMenu())
+			} else if err != nil {
 				return c.Send("Ошибка: " + err.Error())
 			}
 
 			bt.clearApply(userID)
 			bt.setState(userID, StateMainMenu)
-			_ = c.Send("Заявка отправлена! Администратор скоро её рассмотрит.")
+			bt.notifyApplication(app, admitted)
+			_ = c.Send(bt.applicationSentText(app, admitted))
 			return c.Send("Выберите действие", mainMenu())
+		case StateAdminAwaitRejectReason:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+			return bt.rejectApplication(c, ctx.TournamentID, ctx.Applicant, strings.TrimSpace(c.Text()))
+		case StateAdminAwaitScoring:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+
+			var values []float64
+			for _, field := range strings.Fields(strings.ReplaceAll(c.Text(), ",", ".")) {
+				v, err := strconv.ParseFloat(field, 64)
+				if err != nil {
+					break
+				}
+				values = append(values, v)
+			}
+			if len(values) != 6 {
+				return c.Send("Нужно ровно шесть чисел, например: 3 1 0 3 3 0. Попробуйте ещё раз.")
+			}
+			rules := domain.ScoringRules{
+				Win: values[0], Draw: values[1], Loss: values[2],
+				Bye: values[3], ForfeitWin: values[4], ForfeitLoss: values[5],
+			}
+
+			t, err := bt.svc.SetScoringRules(ctx.TournamentID, userID, rules)
+			if errors.Is(err, domain.ErrInvalidScoringRules) {
+				return c.Send("Победа должна давать не меньше очков, чем ничья, а ничья — не меньше, чем поражение. Попробуйте ещё раз.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearAdminCtx(userID)
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("scoring_tournament%d", t.ID))))
+			return c.Send("✅ Система очков обновлена.\n\n"+scoringRulesText(t.Scoring), menu)
+		case StateAdminAwaitSeedOrder, StateAdminAwaitRatings:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+
+			var t *domain.Tournament
+			var err error
+			if state == StateAdminAwaitSeedOrder {
+				var order []domain.ParticipantID
+				for _, field := range strings.Fields(c.Text()) {
+					id, convErr := strconv.ParseInt(field, 10, 64)
+					if convErr != nil {
+						return c.Send("Нужны номера участников через пробел. Попробуйте ещё раз.")
+					}
+					order = append(order, domain.ParticipantID(id))
+				}
+				t, err = bt.svc.SetSeeds(ctx.TournamentID, userID, order)
+			} else {
+				ratings := make(map[domain.ParticipantID]int)
+				for _, line := range strings.Split(c.Text(), "\n") {
+					fields := strings.Fields(line)
+					if len(fields) == 0 {
+						continue
+					}
+					if len(fields) != 2 {
+						return c.Send("Каждая строка — «номер рейтинг». Попробуйте ещё раз.")
+					}
+					id, err1 := strconv.ParseInt(fields[0], 10, 64)
+					rating, err2 := strconv.Atoi(fields[1])
+					if err1 != nil || err2 != nil {
+						return c.Send("Каждая строка — «номер рейтинг». Попробуйте ещё раз.")
+					}
+					ratings[domain.ParticipantID(id)] = rating
+				}
+				t, err = bt.svc.SetParticipantRatings(ctx.TournamentID, userID, ratings)
+			}
+			if errors.Is(err, domain.ErrUnknownParticipant) || errors.Is(err, domain.ErrDuplicateSeed) {
+				return c.Send("Проверьте номера участников: " + err.Error() + ". Попробуйте ещё раз.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearAdminCtx(userID)
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ К посеву", fmt.Sprintf("seeding_tournament%d", t.ID))))
+			return c.Send("✅ Посев обновлён.", menu)
 		case StateAdminAwaitMatchID:
 			adminID := domain.TelegramUserID(c.Sender().ID)
 			ctx := bt.getAdminCtx(adminID)
@@ -511,23 +1766,12 @@ func registerHandlers(bt *Bot) {
 			}
 			mID := domain.MatchID(mID64)
 
-			t, err := bt.svc.GetTournament(ctx.TournamentID)
+			t, err := bt.svc.GetManagedTournament(ctx.TournamentID, adminID, domain.PermResults)
 			if err != nil {
 				return c.Send("Ошибка: " + err.Error())
 			}
 
-			var match *domain.Match
-			for _, ms := range t.Matches {
-				for _, m := range ms {
-					if m.ID == mID {
-						match = m
-						break
-					}
-				}
-				if match != nil {
-					break
-				}
-			}
+			match := t.FindMatch(mID)
 			if match == nil {
 				return c.Send("Матч с таким ID не найден. Введите другой ID.")
 			}
@@ -537,26 +1781,321 @@ func registerHandlers(bt *Bot) {
 			btnP1 := menu.Data("🏆 Выиграл первый", fmt.Sprintf("adm_apply_result_%d_%d_p1", t.ID, mID))
 			btnDraw := menu.Data("🤝 Ничья", fmt.Sprintf("adm_apply_result_%d_%d_draw", t.ID, mID))
 			btnP2 := menu.Data("🏆 Выиграл второй", fmt.Sprintf("adm_apply_result_%d_%d_p2", t.ID, mID))
+			btnFfP1 := menu.Data("⚠️ Тех. победа первого", fmt.Sprintf("adm_apply_result_%d_%d_p1ff", t.ID, mID))
+			btnFfP2 := menu.Data("⚠️ Тех. победа второго", fmt.Sprintf("adm_apply_result_%d_%d_p2ff", t.ID, mID))
 			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("adm_matches_tournament%d", t.ID))
-			menu.Inline(menu.Row(btnP1, btnDraw, btnP2), menu.Row(btnBack))
+			if t.System.Elimination() {
+				menu.Inline(menu.Row(btnP1, btnP2), menu.Row(btnFfP1, btnFfP2), menu.Row(btnBack))
+			} else {
+				menu.Inline(menu.Row(btnP1, btnDraw, btnP2), menu.Row(btnFfP1, btnFfP2), menu.Row(btnBack))
+			}
 
-			bt.setState(adminID, StateMainMenu) // выходим из режима ввода
-			bt.clearAdminCtx(adminID)
+			// ждём счёт, если вместо кнопки пришлют его
+			bt.setState(adminID, StateAdminAwaitMatchScore)
+			bt.setAdminCtx(adminID, &adminSetResultCtx{TournamentID: t.ID, MatchID: mID})
 
 			return c.Send(
-				fmt.Sprintf("Матч ID %d:\nP1=%d, P2=%d.\nВыберите результат:", mID, match.P1, match.P2),
+				fmt.Sprintf("Матч ID %d:\nP1=%d, P2=%d.\nВыберите результат или отправьте счёт, начиная с первого участника (например 3:1 или 11:9 8:11 11:5):", mID, match.P1, match.P2),
 				menu,
 			)
+		case StateAdminAwaitMatchScore:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+			games, err := domain.ParseScore(c.Text())
+			if err != nil {
+				return c.Send("Не понял счёт. Пример: 3:1 или 11:9 8:11 11:5. Попробуйте ещё раз.")
+			}
+
+			t, err := bt.svc.SetMatchScoreByAdmin(ctx.TournamentID, ctx.MatchID, userID, games)
+			if errors.Is(err, domain.ErrNoLegalPairing) {
+				_ = c.Send("⚠️ Следующий раунд невозможно составить: все оставшиеся пары уже играли друг с другом.")
+			} else if msg, ok := scoreErrorText(err); ok {
+				return c.Send(msg)
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearAdminCtx(userID)
+
+			menu := currentMatchesMenu(t)
+			return c.Send("✅ Счёт матча обновлён администратором.\n\n"+buildCurrentMatchesText(t), menu)
+		case StatePlayerAwaitScore:
+			ctx := bt.getReportCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия сброшена. Выберите действие", mainMenu())
+			}
+			games, err := domain.ParseScore(c.Text())
+			if err != nil {
+				return c.Send("Не понял счёт. Пример: 3:1 или 11:9 8:11 11:5. Попробуйте ещё раз.")
+			}
+
+			t, err := bt.svc.ReportMatchScore(ctx.TournamentID, ctx.MatchID, userID, games)
+			if errors.Is(err, domain.ErrNoLegalPairing) {
+				_ = c.Send("⚠️ Результат записан, но следующий раунд невозможно составить без повторных встреч. Сообщите организатору.")
+			} else if msg, ok := scoreErrorText(err); ok {
+				return c.Send(msg)
+			} else if err != nil {
+				return c.Send("Ошибка при отправке результата: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearReportCtx(userID)
+			if m := t.FindMatch(ctx.MatchID); m != nil && m.State == domain.MatchConflicted {
+				bt.notifyConflict(t, m)
+			}
+
+			p := t.FindParticipantBytgID(userID)
+			if p == nil {
+				return c.Send("✅ Ваш отчёт о матче принят.", mainMenu())
+			}
+			menu := &tb.ReplyMarkup{}
+			btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
+			menu.Inline(menu.Row(btnBack))
+			return c.Send("✅ Ваш отчёт о матче принят.\n\n"+t.GetMatchesHistory(p.ID), menu)
+		case StatePlayerAwaitMatchTime:
+			ctx := bt.getReportCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия сброшена. Выберите действие", mainMenu())
+			}
+			at, err := time.ParseInLocation(deadlineLayout, strings.TrimSpace(c.Text()), bt.userLocation(userID))
+			if err != nil {
+				return c.Send("Нужна дата и время, например 25.05.2025 18:00. Попробуйте ещё раз.")
+			}
+
+			t, err := bt.svc.ProposeMatchTime(ctx.TournamentID, ctx.MatchID, userID, at)
+			if errors.Is(err, domain.ErrTimeInPast) {
+				return c.Send("Это время уже прошло. Введите другое.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearReportCtx(userID)
+			bt.notifyProposal(t, t.FindMatch(ctx.MatchID), t.FindParticipantBytgID(userID))
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("pmatches_tournament%d_%d", t.ID, userID))))
+			return c.Send("✅ Предложение отправлено сопернику.", menu)
+		case StatePlayerAwaitEvidence:
+			ctx := bt.getReportCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия сброшена. Выберите действие", mainMenu())
+			}
+			return bt.addEvidence(c, &domain.Evidence{
+				TournamentID: ctx.TournamentID,
+				MatchID:      ctx.MatchID,
+				UserID:       userID,
+				Text:         c.Text(),
+			})
+		case StateAdminAwaitResolution:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+			return bt.resolveConflict(c, ctx.TournamentID, ctx.MatchID, ctx.Resolution, strings.TrimSpace(c.Text()))
+		case StateAdminAwaitFormQuestion:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+			lines := strings.Split(strings.TrimSpace(c.Text()), "\n")
+			f := &domain.FormField{Question: lines[0], Kind: ctx.FieldKind, Options: lines[1:], Required: true}
+
+			t, err := bt.svc.AddFormField(ctx.TournamentID, userID, f)
+			if errors.Is(err, domain.ErrInvalidField) {
+				if ctx.FieldKind == domain.FieldChoice {
+					return c.Send("Нужен вопрос и хотя бы два варианта ответа, каждый на своей строке. Попробуйте ещё раз.")
+				}
+				return c.Send("Вопрос не может быть пустым. Попробуйте ещё раз.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearAdminCtx(userID)
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ К анкете", fmt.Sprintf("form_tournament%d", t.ID))))
+			return c.Send("✅ Вопрос добавлен, он обязательный — это можно изменить в анкете.\n\n"+formText(t), menu)
+		case StateAdminAwaitCapacity:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(c.Text()))
+			if err != nil || n < 0 {
+				return c.Send("Нужно целое число не меньше нуля. Попробуйте ещё раз.")
+			}
+
+			t, err := bt.svc.SetCapacity(ctx.TournamentID, userID, n)
+			if errors.Is(err, domain.ErrCapacityTooSmall) {
+				return c.Send("Участников уже больше. Введите лимит побольше или сначала снимите кого-нибудь.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearAdminCtx(userID)
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("reg_tournament%d", t.ID))))
+			return c.Send("✅ Лимит участников сохранён.\n\n"+registrationText(t, bt.userLocation(userID)), menu)
+		case StateAdminAwaitProfileField:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+			value := strings.TrimSpace(c.Text())
+			if value == "-" {
+				value = ""
+			}
+
+			t, err := bt.svc.SetProfileField(ctx.TournamentID, userID, ctx.ProfileField, value)
+			if errors.Is(err, domain.ErrProfileTooLong) {
+				return c.Send(fmt.Sprintf("Слишком длинный текст, допустимо до %d символов. Попробуйте ещё раз.", domain.MaxProfileLength))
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearAdminCtx(userID)
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("profile_tournament%d", t.ID))))
+			return c.Send("✅ Сохранено.\n\n"+profileCard(t, bt.userLocation(userID)), menu, tb.ModeHTML)
+		case StateAdminAwaitStartDate:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+			loc := bt.userLocation(userID)
+			at, err := time.ParseInLocation(deadlineLayout, strings.TrimSpace(c.Text()), loc)
+			if err != nil {
+				return c.Send("Нужна дата и время, например 25.05.2025 18:00. Попробуйте ещё раз.")
+			}
+
+			t, err := bt.svc.SetStartDate(ctx.TournamentID, userID, &at)
+			if errors.Is(err, domain.ErrTimeInPast) {
+				return c.Send("Это время уже прошло. Введите другое.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearAdminCtx(userID)
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("profile_tournament%d", t.ID))))
+			return c.Send("✅ Дата начала сохранена.\n\n"+profileCard(t, loc), menu, tb.ModeHTML)
+		case StateAdminAwaitRegDeadline:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+			loc := bt.userLocation(userID)
+			at, err := time.ParseInLocation(deadlineLayout, strings.TrimSpace(c.Text()), loc)
+			if err != nil {
+				return c.Send("Нужна дата и время, например 25.05.2025 18:00. Попробуйте ещё раз.")
+			}
+
+			t, err := bt.svc.SetRegistrationDeadline(ctx.TournamentID, userID, &at)
+			if errors.Is(err, domain.ErrTimeInPast) {
+				return c.Send("Это время уже прошло. Введите другое.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearAdminCtx(userID)
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("reg_tournament%d", t.ID))))
+			return c.Send("✅ Срок приёма заявок сохранён.\n\n"+registrationText(t, loc), menu)
+		case StateAdminAwaitDeadline:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+			round, at, ok := strings.Cut(strings.TrimSpace(c.Text()), " ")
+			r, err1 := strconv.Atoi(round)
+			deadline, err2 := time.ParseInLocation(deadlineLayout, strings.TrimSpace(at), bt.userLocation(userID))
+			if !ok || err1 != nil || err2 != nil {
+				return c.Send("Нужен номер раунда и дата, например: 2 25.05.2025 18:00. Попробуйте ещё раз.")
+			}
+
+			t, err := bt.svc.SetDeadline(ctx.TournamentID, userID, domain.Round(r), deadline)
+			if errors.Is(err, domain.ErrRoundOver) {
+				return c.Send("Этот раунд уже сыгран. Укажите текущий или будущий раунд.")
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearAdminCtx(userID)
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("deadline_tournament%d", t.ID))))
+			return c.Send("✅ Дедлайн установлен.\n\n"+deadlinesText(t, bt.userLocation(userID)), menu)
+		case StateAdminAwaitBestOf:
+			ctx := bt.getAdminCtx(userID)
+			if ctx == nil {
+				bt.setState(userID, StateMainMenu)
+				return c.Send("Сессия админа сброшена.", mainMenu())
+			}
+			fields := strings.Fields(c.Text())
+			if len(fields) != 2 {
+				return c.Send("Нужно два числа: номер раунда и число игр, например: 5 3. Попробуйте ещё раз.")
+			}
+			round, err1 := strconv.Atoi(fields[0])
+			bestOf, err2 := strconv.Atoi(fields[1])
+			if err1 != nil || err2 != nil || round < 1 {
+				return c.Send("Нужно два числа: номер раунда и число игр, например: 5 3. Попробуйте ещё раз.")
+			}
+
+			t, err := bt.svc.SetBestOf(ctx.TournamentID, userID, domain.Round(round), bestOf)
+			if errors.Is(err, domain.ErrInvalidBestOf) {
+				return c.Send(fmt.Sprintf("Число игр должно быть от 1 до %d. Попробуйте ещё раз.", domain.MaxBestOf))
+			} else if err != nil {
+				return c.Send("Ошибка: " + err.Error())
+			}
+			bt.setState(userID, StateMainMenu)
+			bt.clearAdminCtx(userID)
+
+			menu := &tb.ReplyMarkup{}
+			menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("bestof_tournament%d", t.ID))))
+			return c.Send("✅ Формат матчей обновлён.\n\n"+bestOfText(t), menu)
 		}
 
 		return c.Send("Не распознал команду")
 	})
 }
 
+func (bt *Bot) createTournament(c tb.Context, t *domain.Tournament) error {
+	t, err := bt.svc.CreateTournament(t)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	bt.clearCreateCtx(t.OwnerID)
+	bt.setState(t.OwnerID, StateTournamentManagement)
+	_ = c.Edit(fmt.Sprintf("Турнир '%s' создан! ID: %d\nСистема: %s", t.Title, t.ID, systemName(t.System)))
+
+	tournaments, err := bt.svc.GetUserTournaments(t.OwnerID)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+
+	return sendTournamentsPage(c, tournaments, 0)
+}
+
 func buildCurrentMatchesText(t *domain.Tournament) string {
 	var lines []string
 	lines = append(lines, fmt.Sprintf("Текущие матчи турнира «%s» (Раунд %d):", t.Title, t.CurrentRound))
-	
+
 	name := func(id domain.ParticipantID) string {
 		for _, p := range t.Participants {
 			if p.ID == id {
@@ -573,7 +2112,19 @@ func buildCurrentMatchesText(t *domain.Tournament) string {
 				continue
 			}
 			has = true
-			lines = append(lines, fmt.Sprintf("• #%d: %s vs %s", m.ID, name(m.P1), name(m.P2)))
+			var bracket string
+			if title := m.Bracket.Title(); title != "" {
+				bracket = " (" + title + ")"
+			}
+			if bestOf := t.BestOfRound(m.Round); bestOf > 1 {
+				bracket += fmt.Sprintf(" [Bo%d]", bestOf)
+			}
+			lines = append(lines, fmt.Sprintf("• #%d%s: %s vs %s", m.ID, bracket, name(m.P1), name(m.P2)))
+			if m.State == domain.MatchConflicted {
+				lines = append(lines,
+					fmt.Sprintf("   ⚖️ %s: %s", name(m.P1), claimText(t, m, m.OpinionP1, m.ClaimP1)),
+					fmt.Sprintf("   ⚖️ %s: %s", name(m.P2), claimText(t, m, m.OpinionP2, m.ClaimP2)))
+			}
 		}
 	}
 	if !has {
@@ -581,3 +2132,126 @@ func buildCurrentMatchesText(t *domain.Tournament) string {
 	}
 	return strings.Join(lines, "\n")
 }
+
+func (bt *Bot) resolveConflict(c tb.Context, tID domain.TournamentID, mID domain.MatchID, resolution domain.Resolution, note string) error {
+	adminID := domain.TelegramUserID(c.Sender().ID)
+	t, err := bt.svc.ResolveConflict(tID, mID, adminID, resolution, note)
+	if errors.Is(err, domain.ErrNoLegalPairing) {
+		_ = c.Send("⚠️ Следующий раунд невозможно составить: все оставшиеся пары уже играли друг с другом.")
+	} else if errors.Is(err, domain.ErrNotConflicted) {
+		bt.setState(adminID, StateMainMenu)
+		bt.clearAdminCtx(adminID)
+		return c.Send("Спор по этому матчу уже решён.")
+	} else if errors.Is(err, domain.ErrUnknownResolution) {
+		bt.setState(adminID, StateMainMenu)
+		bt.clearAdminCtx(adminID)
+		return c.Send("Ошибка: неизвестное решение спора, откройте матч заново.")
+	} else if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	bt.setState(adminID, StateMainMenu)
+	bt.clearAdminCtx(adminID)
+	bt.notifyResolution(t, t.FindMatch(mID))
+
+	return c.Send("✅ Спор решён.\n\n"+buildCurrentMatchesText(t), currentMatchesMenu(t))
+}
+
+func scoreErrorText(err error) (string, bool) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidScore):
+		return "Счёт не подходит к формату матча: серия должна закончиться, как только кто-то набрал нужное число побед, а партии — без ничьих. Попробуйте ещё раз.", true
+	case errors.Is(err, domain.ErrDrawNotAllowed):
+		return "Ничья в этом матче невозможна. Введите счёт ещё раз.", true
+	}
+	return "", false
+}
+
+func (bt *Bot) rejectApplication(c tb.Context, tID domain.TournamentID, tgID domain.TelegramUserID, reason string) error {
+	adminID := domain.TelegramUserID(c.Sender().ID)
+	app, err := bt.svc.GetApplication(tID, tgID)
+	if err != nil {
+		return c.Send("Ошибка при получении заявки: " + err.Error())
+	}
+	if app == nil {
+		bt.setState(adminID, StateMainMenu)
+		bt.clearAdminCtx(adminID)
+		return c.Send("Заявка уже рассмотрена")
+	}
+	t, err := bt.svc.GetTournament(tID)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+
+	if err := bt.svc.RejectApplication(tID, adminID, tgID, reason); err != nil {
+		return c.Send("Ошибка при отклонении: " + err.Error())
+	}
+	bt.setState(adminID, StateMainMenu)
+	bt.clearAdminCtx(adminID)
+
+	text := fmt.Sprintf("❌ Ваша заявка на турнир «%s» отклонена.", t.Title)
+	if reason != "" {
+		text += "\nПричина: " + reason
+	}
+	for _, uid := range app.Roster() {
+		bt.notify(uid, text)
+	}
+
+	tag := ""
+	if app.TelegramTag != nil {
+		tag = "(@" + *app.TelegramTag + ")"
+	}
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data("Остальные заявки", fmt.Sprintf("applications_tournament%d", tID))))
+	return c.Send(fmt.Sprintf("❌ Заявка пользователя %s%s на турнир «%s» отклонена.", app.Name, tag, t.Title), menu)
+}
+
+func (bt *Bot) applicationSentText(app *domain.Application, admitted bool) string {
+	text := "Заявка отправлена! Администратор скоро её рассмотрит."
+	switch {
+	case admitted:
+		text = "✅ Вы зарегистрированы на турнир!"
+	case app.Waitlisted:
+		text = "Все места заняты — заявка попала в лист ожидания. Если место освободится до старта, мы сообщим."
+	}
+	if app.InviteCode != "" {
+		text += fmt.Sprintf("\n\nПригласите игроков в команду «%s» по ссылке https://t.me/%s?start=team_%s "+
+			"или попросите их отправить боту /join %s", app.Name, bt.bot.Me.Username, app.InviteCode, app.InviteCode)
+	}
+	return text
+}
+
+func (bt *Bot) joinStaff(c tb.Context, code string) error {
+	uid := domain.TelegramUserID(c.Sender().ID)
+	name := strings.TrimSpace(c.Sender().FirstName + " " + c.Sender().LastName)
+	if c.Sender().Username != "" {
+		name += " (@" + c.Sender().Username + ")"
+	}
+
+	t, m, err := bt.svc.AcceptRoleInvite(code, uid, name)
+	if errors.Is(err, domain.ErrInviteNotFound) {
+		return c.Send("Приглашение не найдено или уже использовано.", mainMenu())
+	} else if errors.Is(err, domain.ErrAlreadyParticipant) {
+		return c.Send("Вы участвуете в этом турнире и не можете его судить.", mainMenu())
+	} else if errors.Is(err, domain.ErrOwnerInvite) {
+		return c.Send("Вы владелец этого турнира и уже управляете им.", mainMenu())
+	} else if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+
+	for _, id := range t.StaffWith(domain.PermStaff) {
+		bt.notify(id, fmt.Sprintf("👥 %s теперь %s турнира «%s».", m.Name, roleName(m.Role), t.Title))
+	}
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data("Открыть турнир", fmt.Sprintf("tournament_%d", t.ID))))
+	return c.Send(fmt.Sprintf("✅ Вы %s турнира «%s».", roleName(m.Role), t.Title), menu)
+}
+
+func (bt *Bot) joinTeam(c tb.Context, code string) error {
+	app, err := bt.svc.JoinTeam(code, domain.TelegramUserID(c.Sender().ID))
+	if errors.Is(err, domain.ErrInviteNotFound) {
+		return c.Send("Приглашение не найдено. Проверьте код.", mainMenu())
+	} else if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	return c.Send(fmt.Sprintf("✅ Вы в составе команды «%s». Игроков в заявке: %d.", app.Name, len(app.Roster())), mainMenu())
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"log"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/Ycyken/tournament-bot/internal/domain"
 	tb "gopkg.in/telebot.v3"
@@ -16,12 +18,75 @@ const CreateTournament = "create_tournament"
 const ListTournaments = "list_tournaments"
 const MyTournaments = "my_tournaments"
 const ApplySkipText = "apply_skip_text"
+const MyRating = "my_rating"
+const MyApplications = "my_applications"
+const CreateGameSkip = "create_game_skip"
+const TimezoneMenu = "tz_menu"
+const CreateSystemPrefix = "create_system_"
+const CreateResetPrefix = "create_reset_"
+const CreateCyclesPrefix = "create_cycles_"
+
+func systemName(s domain.System) string {
+	switch s {
+	case domain.Swiss:
+		return "швейцарская"
+	case domain.SingleElimination:
+		return "олимпийская (на выбывание)"
+	case domain.DoubleElimination:
+		return "двойное выбывание"
+	case domain.RoundRobin:
+		return "круговая"
+	}
+	return string(s)
+}
+
+func systemMenu() *tb.ReplyMarkup {
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+	for _, s := range []domain.System{domain.Swiss, domain.RoundRobin, domain.SingleElimination, domain.DoubleElimination} {
+		rows = append(rows, menu.Row(menu.Data("Система: "+systemName(s), CreateSystemPrefix+string(s))))
+	}
+	rows = append(rows, menu.Row(menu.Data("Отменить", MainMenu)))
+	menu.Inline(rows...)
+	return menu
+}
+
+func roundRobinCyclesMenu() *tb.ReplyMarkup {
+	menu := &tb.ReplyMarkup{}
+	btnOne := menu.Data("В один круг", CreateCyclesPrefix+"1")
+	btnTwo := menu.Data("В два круга", CreateCyclesPrefix+"2")
+	menu.Inline(menu.Row(btnOne, btnTwo), menu.Row(menu.Data("Отменить", MainMenu)))
+	return menu
+}
+
+func bracketResetMenu() *tb.ReplyMarkup {
+	menu := &tb.ReplyMarkup{}
+	btnYes := menu.Data("Да, с перезапуском", CreateResetPrefix+"1")
+	btnNo := menu.Data("Нет, один финал", CreateResetPrefix+"0")
+	menu.Inline(menu.Row(btnYes, btnNo), menu.Row(menu.Data("Отменить", MainMenu)))
+	return menu
+}
 
 func mainMenu() *tb.ReplyMarkup {
 	menu := &tb.ReplyMarkup{}
 	btnList := menu.Data("Посмотреть список турниров", ListTournaments)
 	btnMyTs := menu.Data("Мои турниры", MyTournaments)
-	menu.Inline(menu.Row(btnList), menu.Row(btnMyTs))
+	btnMyApps := menu.Data("Мои заявки", MyApplications)
+	btnRating := menu.Data("Мой рейтинг", MyRating)
+	menu.Inline(menu.Row(btnList), menu.Row(btnMyTs), menu.Row(btnMyApps), menu.Row(btnRating))
+	return menu
+}
+
+func gameMenu() *tb.ReplyMarkup {
+	menu := &tb.ReplyMarkup{}
+	btnSkip := menu.Data("Пропустить", CreateGameSkip)
+	menu.Inline(menu.Row(btnSkip), menu.Row(menu.Data("Отменить", MainMenu)))
+	return menu
+}
+
+func backToMainMenu() *tb.ReplyMarkup {
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data("Главное меню", MainMenu)))
 	return menu
 }
 
@@ -75,24 +140,176 @@ func tournamentMenu(c tb.Context, t *domain.Tournament) error {
 	tgID := domain.TelegramUserID(c.Sender().ID)
 
 	btnMain := menu.Data("Главное меню", MainMenu)
-	if tgID != t.OwnerID {
+	if t.RoleOf(tgID) == "" {
 		menu.Inline(menu.Row(btnMain))
-		return c.Send("какая-то инфа о турнире", menu)
+		return c.Edit(profileCard(t, time.Local), menu, tb.ModeHTML)
 	}
+	can := func(perm domain.Permission) bool { return t.Can(tgID, perm) }
+
 	var rows []tb.Row
 	btnApps := menu.Data("Заявки на турнир", fmt.Sprintf("applications_tournament%d", t.ID))
-	btnStart := menu.Data("Начать турнир", fmt.Sprintf("start_tournament%d", t.ID))
 	btnInfo := menu.Data("Информация о турнире", fmt.Sprintf("pinfo_tournament%d", t.ID))
+	btnAbout := menu.Data("📄 О турнире", fmt.Sprintf("about_tournament%d", t.ID))
+	btnProfile := menu.Data("✏️ Описание турнира", fmt.Sprintf("profile_tournament%d", t.ID))
 	btnCur := menu.Data("Текущие матчи", fmt.Sprintf("adm_matches_tournament%d", t.ID))
+	btnRemove := menu.Data("Снять участника", fmt.Sprintf("remove_pick_%d", t.ID))
+	btnTieBreaks := menu.Data("Доп. показатели", fmt.Sprintf("tiebreaks_tournament%d", t.ID))
+	btnScoring := menu.Data("Система очков", fmt.Sprintf("scoring_tournament%d", t.ID))
+	btnBestOf := menu.Data("Формат матчей", fmt.Sprintf("bestof_tournament%d", t.ID))
+	btnDeadlines := menu.Data("⏰ Дедлайны", fmt.Sprintf("deadline_tournament%d", t.ID))
+	btnStaff := menu.Data("👥 Организаторы и судьи", fmt.Sprintf("staff_tournament%d", t.ID))
 
-	if t.CurrentRound == 0 {
-		rows = append(rows, menu.Row(btnApps), menu.Row(btnStart))
-	} else {
-		rows = append(rows, menu.Row(btnCur))
+	btnSeeding := menu.Data("Посев", fmt.Sprintf("seeding_tournament%d", t.ID))
+	btnRegistration := menu.Data("📋 Регистрация", fmt.Sprintf("reg_tournament%d", t.ID))
+	btnTeams := menu.Data("Команды", fmt.Sprintf("teams_tournament%d", t.ID))
+
+	switch {
+	case t.Status.Preparing():
+		if can(domain.PermApplications) && t.Status != domain.StatusDraft {
+			rows = append(rows, menu.Row(btnApps))
+		}
+		if can(domain.PermParticipants) && len(t.Participants) > 0 {
+			rows = append(rows, menu.Row(btnRemove))
+		}
+		if can(domain.PermSettings) {
+			rows = append(rows, menu.Row(btnRegistration), menu.Row(btnTeams), menu.Row(btnSeeding))
+		}
+	case t.Status == domain.StatusRunning:
+		if can(domain.PermResults) {
+			rows = append(rows, menu.Row(btnCur))
+		}
+		if can(domain.PermParticipants) {
+			rows = append(rows, menu.Row(btnRemove))
+		}
+	}
+	if can(domain.PermSettings) && !t.Status.Over() {
+		if !t.System.Elimination() {
+			rows = append(rows, menu.Row(btnTieBreaks), menu.Row(btnScoring))
+		}
+		rows = append(rows, menu.Row(btnProfile), menu.Row(btnBestOf), menu.Row(btnDeadlines))
+	}
+	if can(domain.PermSettings) {
+		rows = append(rows, lifecycleRows(menu, t)...)
 	}
-	rows = append(rows, menu.Row(btnInfo), menu.Row(btnMain))
+	if can(domain.PermStaff) {
+		rows = append(rows, menu.Row(btnStaff))
+	}
+	rows = append(rows, menu.Row(btnAbout), menu.Row(btnInfo), menu.Row(btnMain))
 	menu.Inline(rows...)
-	return c.Edit(fmt.Sprintf("Турнир %s | ID %d", t.Title, t.ID), menu)
+	return c.Edit(fmt.Sprintf("Турнир %s | ID %d\nСтатус: %s\nВаша роль: %s", t.Title, t.ID, statusName(t.Status), roleName(t.RoleOf(tgID))), menu)
+}
+
+// lifecycleRows offers the next stages of the tournament. Stages that
+// cannot be undone are asked to be confirmed first.
+func lifecycleRows(menu *tb.ReplyMarkup, t *domain.Tournament) []tb.Row {
+	set := func(text string, to domain.Status) tb.Row {
+		return menu.Row(menu.Data(text, fmt.Sprintf("status_set_%d_%s", t.ID, to)))
+	}
+	ask := func(text string, to domain.Status) tb.Row {
+		return menu.Row(menu.Data(text, fmt.Sprintf("status_ask_%d_%s", t.ID, to)))
+	}
+
+	var rows []tb.Row
+	switch t.Status {
+	case domain.StatusDraft:
+		rows = append(rows, set("📢 Открыть регистрацию", domain.StatusRegistration))
+	case domain.StatusRegistration:
+		rows = append(rows, set("🔒 Закрыть регистрацию", domain.StatusRegistrationClosed))
+	case domain.StatusRegistrationClosed:
+		rows = append(rows, set("📢 Открыть регистрацию снова", domain.StatusRegistration))
+	case domain.StatusRunning:
+		rows = append(rows, ask("🏁 Завершить турнир", domain.StatusFinished))
+	}
+	if t.Status.Preparing() {
+		rows = append(rows, menu.Row(menu.Data("Начать турнир", fmt.Sprintf("start_tournament%d", t.ID))))
+	}
+	if t.Status.CanMoveTo(domain.StatusCancelled) {
+		rows = append(rows, ask("❌ Отменить турнир", domain.StatusCancelled))
+	}
+	if t.Status.CanMoveTo(domain.StatusArchived) {
+		rows = append(rows, ask("🗄 В архив", domain.StatusArchived))
+	}
+	return rows
+}
+
+func statusName(s domain.Status) string {
+	switch s {
+	case domain.StatusDraft:
+		return "черновик"
+	case domain.StatusRegistration:
+		return "идёт регистрация"
+	case domain.StatusRegistrationClosed:
+		return "регистрация закрыта"
+	case domain.StatusRunning:
+		return "идёт"
+	case domain.StatusFinished:
+		return "завершён"
+	case domain.StatusCancelled:
+		return "отменён"
+	case domain.StatusArchived:
+		return "в архиве"
+	}
+	return string(s)
+}
+
+func statusEmoji(s domain.Status) string {
+	switch s {
+	case domain.StatusRegistration:
+		return "🟢"
+	case domain.StatusRegistrationClosed:
+		return "🟡"
+	case domain.StatusRunning:
+		return "▶️"
+	case domain.StatusFinished:
+		return "🏁"
+	}
+	return ""
+}
+
+func statusConfirmText(t *domain.Tournament, to domain.Status) string {
+	switch to {
+	case domain.StatusFinished:
+		return fmt.Sprintf("Завершить турнир «%s» досрочно? Несыгранные матчи останутся без результата, участники получат итоговые места.", t.Title)
+	case domain.StatusCancelled:
+		return fmt.Sprintf("Отменить турнир «%s»? Вернуть его будет нельзя.", t.Title)
+	case domain.StatusArchived:
+		return fmt.Sprintf("Убрать турнир «%s» в архив? Он пропадёт из списков.", t.Title)
+	}
+	return ""
+}
+
+func roleName(r domain.Role) string {
+	switch r {
+	case domain.RoleOwner:
+		return "владелец"
+	case domain.RoleOrganizer:
+		return "соорганизатор"
+	case domain.RoleJudge:
+		return "судья"
+	}
+	return string(r)
+}
+
+func staffMenu(c tb.Context, t *domain.Tournament) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "Организаторы турнира «%s»\n\n", t.Title)
+	text.WriteString("Соорганизатор ведёт турнир целиком: заявки, настройки, результаты. Судья только вносит результаты и решает споры.\n\n")
+	if len(t.Staff) == 0 {
+		text.WriteString("Пока никого нет.")
+	}
+
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+	for _, m := range t.Staff {
+		fmt.Fprintf(&text, "• %s — %s\n", m.Name, roleName(m.Role))
+		rows = append(rows, menu.Row(menu.Data("❌ "+m.Name, fmt.Sprintf("staff_remove_%d_%d", t.ID, m.UserID))))
+	}
+	btnOrganizer := menu.Data("Пригласить соорганизатора", fmt.Sprintf("staff_invite_%d_%s", t.ID, domain.RoleOrganizer))
+	btnJudge := menu.Data("Пригласить судью", fmt.Sprintf("staff_invite_%d_%s", t.ID, domain.RoleJudge))
+	btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
+	rows = append(rows, menu.Row(btnOrganizer), menu.Row(btnJudge), menu.Row(btnBack))
+	menu.Inline(rows...)
+	return c.Edit(text.String(), menu)
 }
 
 func sendTournamentsPage(c tb.Context, tournaments map[domain.TournamentID]string, page int) error {
@@ -108,6 +325,14 @@ func allTournamentsPage(c tb.Context, ts []*domain.Tournament, page int) error {
 	menu := &tb.ReplyMarkup{}
 	var rows []tb.Row
 
+	// drafts are not announced yet, cancelled and archived ones are of no interest
+	var listed []*domain.Tournament
+	for _, t := range ts {
+		if statusEmoji(t.Status) != "" {
+			listed = append(listed, t)
+		}
+	}
+	ts = listed
 	sort.Slice(ts, func(i, j int) bool { return ts[i].ID < ts[j].ID })
 
 	start := page * pageSize
@@ -121,7 +346,7 @@ func allTournamentsPage(c tb.Context, ts []*domain.Tournament, page int) error {
 	}
 
 	for _, t := range ts[start:end] {
-		btn := menu.Data(fmt.Sprintf("%s | ID %d", t.Title, t.ID), fmt.Sprintf("tournament_%d", t.ID))
+		btn := menu.Data(fmt.Sprintf("%s %s | ID %d", statusEmoji(t.Status), t.Title, t.ID), fmt.Sprintf("tournament_%d", t.ID))
 		rows = append(rows, menu.Row(btn))
 	}
 
@@ -142,16 +367,40 @@ func allTournamentsPage(c tb.Context, ts []*domain.Tournament, page int) error {
 	return c.Edit("Список турниров:", menu)
 }
 
-func applicationMenu(c tb.Context, t *domain.Tournament, apps []*domain.Application) error {
+func applicationCard(c tb.Context, t *domain.Tournament, apps []*domain.Application, app *domain.Application) error {
 	menu := &tb.ReplyMarkup{}
-	btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
+	btnApprove := menu.Data("✅ Принять", fmt.Sprintf("app_approve_%d_%d", app.TournamentID, app.TelegramUserID))
+	btnReject := menu.Data("❌ Отклонить", fmt.Sprintf("app_reject_%d_%d", app.TournamentID, app.TelegramUserID))
+	btnBack := menu.Data("⬅️ К списку", fmt.Sprintf("applications_tournament%d", t.ID))
 
-	if len(apps) == 0 {
-		menu.Inline(menu.Row(btnBack))
-		return c.Edit("Заявок нет", menu)
+	text := seatsText(t)
+	// the waitlist is let in automatically
+	if app.Waitlisted {
+		text += fmt.Sprintf("⏳ Лист ожидания, место %d\n", waitlistPlace(domain.Waitlist(apps), app))
+		menu.Inline(menu.Row(btnReject), menu.Row(btnBack))
+	} else {
+		menu.Inline(menu.Row(btnApprove, btnReject), menu.Row(btnBack))
+	}
+	return c.Edit(text+"\n"+applicationText(t, app), menu)
+}
+
+func seatsText(t *domain.Tournament) string {
+	if t.Capacity == 0 {
+		return fmt.Sprintf("Участников: %d\n", len(t.Participants))
 	}
-	app := apps[0]
+	return fmt.Sprintf("Участников: %d из %d\n", len(t.Participants), t.Capacity)
+}
 
+func waitlistPlace(waitlist []*domain.Application, app *domain.Application) int {
+	for i, a := range waitlist {
+		if a.TelegramUserID == app.TelegramUserID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func applicationText(t *domain.Tournament, app *domain.Application) string {
 	text := ""
 	tag := ""
 	if app.Text != nil {
@@ -160,32 +409,351 @@ func applicationMenu(c tb.Context, t *domain.Tournament, apps []*domain.Applicat
 	if app.TelegramTag != nil {
 		tag = "(@" + *app.TelegramTag + ")"
 	}
-	title := fmt.Sprintf(
+	if len(t.Form) > 0 {
+		text = answersText(t, app) + "\n" + text
+	}
+	if t.TeamMode {
+		return fmt.Sprintf(
+			"Заявка команды %s (капитан %s) на турнир %s:\nИгроков в составе: %d\n\n%s",
+			app.Name, tag, t.Title, len(app.Roster()), text,
+		)
+	}
+	return fmt.Sprintf(
 		"Заявка от участника %s %s на турнир %s:\n\n%s",
 		app.Name, tag, t.Title, text,
 	)
+}
 
-	btnApprove := menu.Data("✅ Принять", fmt.Sprintf("app_approve_%d_%d", app.TournamentID, app.TelegramUserID))
-	btnReject := menu.Data("❌ Отклонить", fmt.Sprintf("app_reject_%d_%d", app.TournamentID, app.TelegramUserID))
+func tieBreaksMenu(c tb.Context, t *domain.Tournament) error {
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+
+	text := "Доп. показатели применяются по порядку при равенстве очков.\n\nТекущий порядок:\n"
+	if len(t.TieBreaks) == 0 {
+		text += "— не заданы\n"
+	}
+	used := make(map[domain.TieBreak]bool)
+	for i, tieBreak := range t.TieBreaks {
+		text += fmt.Sprintf("%d. %s\n", i+1, tieBreakName(tieBreak))
+		used[tieBreak] = true
+	}
 
+	for _, tieBreak := range domain.AllTieBreaks {
+		if used[tieBreak] {
+			continue
+		}
+		btn := menu.Data("➕ "+tieBreakName(tieBreak), fmt.Sprintf("tiebreak_add_%d_%s", t.ID, tieBreak))
+		rows = append(rows, menu.Row(btn))
+	}
+	rows = append(rows,
+		menu.Row(menu.Data("🔄 Очистить", fmt.Sprintf("tiebreak_reset_%d", t.ID))),
+		menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))),
+	)
+
+	menu.Inline(rows...)
+	return c.Edit(text, menu)
+}
+
+var scoringPresets = map[string]domain.ScoringRules{
+	"chess":    domain.ChessScoring,
+	"halfbye":  {Win: 1, Draw: 0.5, Loss: 0, Bye: 0.5, ForfeitWin: 1, ForfeitLoss: 0},
+	"football": domain.FootballScoring,
+}
+
+func scoringRulesText(r domain.ScoringRules) string {
+	return fmt.Sprintf("Победа: %g\nНичья: %g\nПоражение: %g\nСвободный раунд: %g\nТехническая победа: %g\nТехническое поражение: %g",
+		r.Win, r.Draw, r.Loss, r.Bye, r.ForfeitWin, r.ForfeitLoss)
+}
+
+func scoringMenu(c tb.Context, t *domain.Tournament) error {
+	menu := &tb.ReplyMarkup{}
+	btnChess := menu.Data("1 – ½ – 0", fmt.Sprintf("scoring_preset_%d_chess", t.ID))
+	btnHalfBye := menu.Data("1 – ½ – 0, бай ½", fmt.Sprintf("scoring_preset_%d_halfbye", t.ID))
+	btnFootball := menu.Data("3 – 1 – 0", fmt.Sprintf("scoring_preset_%d_football", t.ID))
+	btnCustom := menu.Data("✏️ Задать вручную", fmt.Sprintf("scoring_custom_%d", t.ID))
+	btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
 	menu.Inline(
-		menu.Row(btnApprove, btnReject),
+		menu.Row(btnChess, btnHalfBye),
+		menu.Row(btnFootball),
+		menu.Row(btnCustom),
 		menu.Row(btnBack),
 	)
-	return c.Edit(title, menu)
+	return c.Edit("Система очков турнира:\n\n"+scoringRulesText(t.Scoring), menu)
+}
+
+func seedingMethodName(m domain.SeedingMethod) string {
+	switch m {
+	case domain.SeedingRandom:
+		return "случайный"
+	case domain.SeedingManual:
+		return "вручную"
+	case domain.SeedingRating:
+		return "по рейтингу"
+	case domain.SeedingJoinOrder:
+		return "по порядку регистрации"
+	}
+	return string(m)
+}
+
+func seedingMenu(c tb.Context, t *domain.Tournament) error {
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "Посев: %s\n\n", seedingMethodName(t.Seeding))
+	text.WriteString("Участники (номер, рейтинг, место в ручном посеве):\n")
+	for _, p := range t.Participants {
+		fmt.Fprintf(&text, "#%d %s — рейтинг %d", p.ID, p.Name, p.Rating)
+		if t.Seeding == domain.SeedingManual && p.Seed > 0 {
+			fmt.Fprintf(&text, ", посев %d", p.Seed)
+		}
+		text.WriteString("\n")
+	}
+
+	var methods []tb.Btn
+	for _, m := range domain.SeedingMethods {
+		title := seedingMethodName(m)
+		if m == t.Seeding {
+			title = "✅ " + title
+		}
+		methods = append(methods, menu.Data(title, fmt.Sprintf("seeding_method_%d_%s", t.ID, m)))
+	}
+	rows = append(rows,
+		menu.Row(methods[:2]...),
+		menu.Row(methods[2:]...),
+		menu.Row(menu.Data("✏️ Задать порядок", fmt.Sprintf("seeding_order_%d", t.ID))),
+		menu.Row(menu.Data("✏️ Задать рейтинги", fmt.Sprintf("seeding_ratings_%d", t.ID))),
+		menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))),
+	)
+
+	menu.Inline(rows...)
+	return c.Edit(text.String(), menu)
 }
 
 func participantMenu(c tb.Context, t *domain.Tournament, tgID domain.TelegramUserID) error {
 	menu := &tb.ReplyMarkup{}
 
+	btnAbout := menu.Data("📄 О турнире", fmt.Sprintf("about_tournament%d", t.ID))
 	btnInfo := menu.Data("ℹ️ Информация о турнире", fmt.Sprintf("pinfo_tournament%d", t.ID))
 	btnMyMatches := menu.Data("📅 Мои матчи", fmt.Sprintf("pmatches_tournament%d_%d", t.ID, tgID))
+	btnWithdraw := menu.Data("🚪 Сняться с турнира", fmt.Sprintf("withdraw_ask_%d", t.ID))
 	btnMain := menu.Data("Главное меню", MainMenu)
 
-	menu.Inline(
-		menu.Row(btnInfo),
-		menu.Row(btnMyMatches),
-		menu.Row(btnMain),
-	)
+	rows := []tb.Row{menu.Row(btnAbout), menu.Row(btnInfo), menu.Row(btnMyMatches)}
+	p := t.FindParticipantBytgID(tgID)
+	if (t.Status.Preparing() || t.Status == domain.StatusRunning) && !p.Withdrawn && (!t.TeamMode || p.Captain == tgID) {
+		rows = append(rows, menu.Row(btnWithdraw))
+	}
+	rows = append(rows, menu.Row(btnMain))
+	menu.Inline(rows...)
 	return c.Edit(fmt.Sprintf("Турнир %s | ID %d", t.Title, t.ID), menu)
 }
+
+func bestOfName(n int) string {
+	if n <= 1 {
+		return "одна игра"
+	}
+	return fmt.Sprintf("до %d побед (Bo%d)", n/2+1, n)
+}
+
+func bestOfText(t *domain.Tournament) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "По умолчанию: %s\n", bestOfName(t.BestOf))
+
+	var rounds []int
+	for r := range t.RoundBestOf {
+		rounds = append(rounds, int(r))
+	}
+	sort.Ints(rounds)
+	for _, r := range rounds {
+		fmt.Fprintf(&text, "Раунд %d: %s\n", r, bestOfName(t.RoundBestOf[domain.Round(r)]))
+	}
+	return text.String()
+}
+
+func bestOfMenu(c tb.Context, t *domain.Tournament) error {
+	menu := &tb.ReplyMarkup{}
+	var presets []tb.Btn
+	for _, n := range []int{1, 3, 5, 7} {
+		presets = append(presets, menu.Data(fmt.Sprintf("Bo%d", n), fmt.Sprintf("bestof_set_%d_%d", t.ID, n)))
+	}
+	btnRound := menu.Data("✏️ Для отдельного раунда", fmt.Sprintf("bestof_round_%d", t.ID))
+	btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
+	menu.Inline(menu.Row(presets...), menu.Row(btnRound), menu.Row(btnBack))
+	return c.Edit("Формат матчей турнира:\n\n"+bestOfText(t), menu)
+}
+
+func teamsMenu(c tb.Context, t *domain.Tournament) error {
+	menu := &tb.ReplyMarkup{}
+	mode := "одиночный"
+	if t.TeamMode {
+		mode = "командный"
+	}
+	reports := "только капитан"
+	if t.RosterReports {
+		reports = "любой игрок команды"
+	}
+
+	btnMode := menu.Data("Сделать командным", fmt.Sprintf("teams_set_%d_true_%t", t.ID, t.RosterReports))
+	if t.TeamMode {
+		btnMode = menu.Data("Сделать одиночным", fmt.Sprintf("teams_set_%d_false_%t", t.ID, t.RosterReports))
+	}
+	btnReports := menu.Data("Отчёты: любой игрок", fmt.Sprintf("teams_set_%d_%t_true", t.ID, t.TeamMode))
+	if t.RosterReports {
+		btnReports = menu.Data("Отчёты: только капитан", fmt.Sprintf("teams_set_%d_%t_false", t.ID, t.TeamMode))
+	}
+	btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
+
+	rows := []tb.Row{menu.Row(btnMode)}
+	if t.TeamMode {
+		rows = append(rows, menu.Row(btnReports))
+	}
+	rows = append(rows, menu.Row(btnBack))
+	menu.Inline(rows...)
+
+	text := fmt.Sprintf("Режим турнира: %s\n", mode)
+	if t.TeamMode {
+		text += fmt.Sprintf("Результаты матчей сообщает: %s\n\n"+
+			"Капитан подаёт заявку с названием команды и получает ссылку-приглашение для игроков.", reports)
+	}
+	return c.Edit(text, menu)
+}
+
+func removeParticipantMenu(c tb.Context, t *domain.Tournament) error {
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+	for _, p := range t.Participants {
+		if p.Withdrawn {
+			continue
+		}
+		rows = append(rows, menu.Row(menu.Data(p.Name, fmt.Sprintf("remove_ask_%d_%d", t.ID, p.ID))))
+	}
+	rows = append(rows, menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))))
+	menu.Inline(rows...)
+	if t.Status.Preparing() {
+		return c.Edit("Кого снять с турнира? Его место займёт следующий из листа ожидания.", menu)
+	}
+	return c.Edit("Кого снять с турнира? Его текущий матч будет засчитан как техническое поражение.", menu)
+}
+
+const deadlineLayout = "02.01.2006 15:04"
+
+func registrationText(t *domain.Tournament, loc *time.Location) string {
+	var text strings.Builder
+	text.WriteString("Настройки регистрации:\n\n")
+	text.WriteString(seatsText(t))
+	if t.Capacity == 0 {
+		text.WriteString("Лимит участников: нет\n")
+	} else {
+		fmt.Fprintf(&text, "Лимит участников: %d\n", t.Capacity)
+	}
+	if t.RegistrationDeadline == nil {
+		text.WriteString("Приём заявок до: без срока\n")
+	} else {
+		fmt.Fprintf(&text, "Приём заявок до: %s\n", formatTime(*t.RegistrationDeadline, loc))
+	}
+	if t.AutoApprove {
+		text.WriteString("Автоодобрение: включено\n")
+		if t.TeamMode {
+			text.WriteString("Заявки команд всё равно проверяются вручную, когда состав собран.\n")
+		}
+	} else {
+		text.WriteString("Автоодобрение: выключено\n")
+	}
+	text.WriteString("\nКогда мест нет, новые заявки попадают в лист ожидания. Если кто-то снимется до старта, первый в листе займёт его место.")
+	return text.String()
+}
+
+func registrationMenu(c tb.Context, t *domain.Tournament, loc *time.Location) error {
+	menu := &tb.ReplyMarkup{}
+	auto := "Включить автоодобрение"
+	if t.AutoApprove {
+		auto = "Выключить автоодобрение"
+	}
+	rows := []tb.Row{
+		menu.Row(menu.Data("👥 Лимит участников", fmt.Sprintf("reg_capacity_%d", t.ID))),
+		menu.Row(menu.Data("⏰ Срок приёма заявок", fmt.Sprintf("reg_deadline_%d", t.ID))),
+		menu.Row(menu.Data(auto, fmt.Sprintf("reg_auto_%d_%t", t.ID, !t.AutoApprove))),
+		menu.Row(menu.Data("📝 Анкета участника", fmt.Sprintf("form_tournament%d", t.ID))),
+	}
+	if t.RegistrationDeadline != nil {
+		rows = append(rows, menu.Row(menu.Data("Убрать срок", fmt.Sprintf("reg_nodeadline_%d", t.ID))))
+	}
+	rows = append(rows, menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))))
+	menu.Inline(rows...)
+	return c.Edit(registrationText(t, loc), menu)
+}
+
+func deadlinePolicyName(p domain.DeadlinePolicy) string {
+	if p == domain.DeadlineForfeit {
+		return "технические поражения (конфликты и сетка — организатору)"
+	}
+	return "сообщать организатору"
+}
+
+func deadlinesText(t *domain.Tournament, loc *time.Location) string {
+	var text strings.Builder
+	fmt.Fprintf(&text, "Когда дедлайн истёк: %s\n\n", deadlinePolicyName(t.DeadlinePolicy))
+
+	var rounds []int
+	for r := range t.Deadlines {
+		rounds = append(rounds, int(r))
+	}
+	sort.Ints(rounds)
+	if len(rounds) == 0 {
+		text.WriteString("Дедлайны не заданы.\n")
+	}
+	for _, r := range rounds {
+		d := t.Deadlines[domain.Round(r)]
+		status := ""
+		if d.Expired {
+			status = " (истёк)"
+		}
+		fmt.Fprintf(&text, "Раунд %d: %s%s\n", r, formatTime(d.At, loc), status)
+	}
+	return text.String()
+}
+
+func deadlineMenu(c tb.Context, t *domain.Tournament, loc *time.Location) error {
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+	if t.CurrentRound > 0 {
+		var quick []tb.Btn
+		for _, h := range []int{24, 48, 72} {
+			quick = append(quick, menu.Data(fmt.Sprintf("+%d ч", h), fmt.Sprintf("deadline_quick_%d_%d", t.ID, h)))
+		}
+		rows = append(rows, menu.Row(quick...))
+	}
+	rows = append(rows, menu.Row(menu.Data("✏️ Задать дату", fmt.Sprintf("deadline_custom_%d", t.ID))))
+
+	policy := domain.DeadlineForfeit
+	if t.DeadlinePolicy == domain.DeadlineForfeit {
+		policy = domain.DeadlineEscalate
+	}
+	rows = append(rows,
+		menu.Row(menu.Data("Политика: "+deadlinePolicyName(policy), fmt.Sprintf("deadline_policy_%d_%s", t.ID, policy))),
+		menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))),
+	)
+	menu.Inline(rows...)
+
+	text := "Дедлайны раундов турнира:\n\n" + deadlinesText(t, loc)
+	if t.CurrentRound > 0 {
+		text += fmt.Sprintf("\nКнопки «+N ч» ставят дедлайн текущего раунда (%d) через N часов.", t.CurrentRound)
+	}
+	return c.Edit(text, menu)
+}
+
+func currentMatchesMenu(t *domain.Tournament) *tb.ReplyMarkup {
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+	for _, m := range t.Matches[t.CurrentRound] {
+		if m.State == domain.MatchConflicted {
+			rows = append(rows, menu.Row(menu.Data(fmt.Sprintf("⚖️ Спор #%d", m.ID), fmt.Sprintf("conflict_view_%d_%d", t.ID, m.ID))))
+		}
+	}
+	btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
+	btnSet := menu.Data("✏️ Изменить результат", fmt.Sprintf("adm_setresult_%d", t.ID))
+	rows = append(rows, menu.Row(btnSet), menu.Row(btnBack))
+	menu.Inline(rows...)
+	return menu
+}
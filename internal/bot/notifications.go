@@ -0,0 +1,134 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+	tb "gopkg.in/telebot.v3"
+)
+
+// RoundDrawn messages every participant still in the tournament with
+// their match of the new round.
+func (b *Bot) RoundDrawn(t *domain.Tournament) {
+	for _, p := range t.Participants {
+		if p.Withdrawn || p.Eliminated {
+			continue
+		}
+		m := t.FindCurrentMatch(p.ID)
+		for _, uid := range p.Roster {
+			text, menu := roundMessage(t, p, m, uid, b.userLocation(uid))
+			b.notify(uid, text, menu)
+		}
+	}
+}
+
+func roundMessage(t *domain.Tournament, p *domain.Participant, m *domain.Match, uid domain.TelegramUserID, loc *time.Location) (string, *tb.ReplyMarkup) {
+	var text strings.Builder
+	fmt.Fprintf(&text, "🔔 Турнир «%s»: начался раунд %d.\n\n", t.Title, t.CurrentRound)
+
+	menu := &tb.ReplyMarkup{}
+	btnMatches := menu.Data("Мои матчи", fmt.Sprintf("pmatches_tournament%d_%d", t.ID, uid))
+	if m == nil {
+		text.WriteString("В этом раунде у вас нет соперника — вы пропускаете тур.")
+		menu.Inline(menu.Row(btnMatches))
+		return text.String(), menu
+	}
+
+	opp := t.FindParticipantByPID(m.P1)
+	if m.P1 == p.ID {
+		opp = t.FindParticipantByPID(m.P2)
+	}
+	fmt.Fprintf(&text, "Матч #%d, ваш соперник: %s%s\n", m.ID, opp.Name, participantTag(opp))
+	if bestOf := t.BestOfRound(m.Round); bestOf > 1 {
+		fmt.Fprintf(&text, "Формат: %s\n", bestOfName(bestOf))
+	}
+	if d := t.Deadlines[t.CurrentRound]; d != nil && !d.Expired {
+		fmt.Fprintf(&text, "⏰ Дедлайн: %s\n", formatTime(d.At, loc))
+	}
+
+	switch {
+	case m.State == domain.MatchCompleted:
+		text.WriteString("\nСоперник снялся, матч засчитан без игры.")
+		menu.Inline(menu.Row(btnMatches))
+	case t.CanReport(p, uid):
+		btnScore := menu.Data("📝 Сообщить счёт", fmt.Sprintf("pmatch_score_%d_%d", t.ID, m.ID))
+		menu.Inline(menu.Row(btnScore), menu.Row(btnMatches))
+	default:
+		text.WriteString("\nРезультат матча сообщает капитан команды.")
+		menu.Inline(menu.Row(btnMatches))
+	}
+	return text.String(), menu
+}
+
+// TournamentFinished tells every participant their final place.
+func (b *Bot) TournamentFinished(t *domain.Tournament) {
+	places := t.FinalPlaces()
+	for _, p := range t.Participants {
+		text := fmt.Sprintf("🏁 Турнир «%s» завершён!\nВаше место: %d из %d.", t.Title, places[p.ID], len(t.Participants))
+		if places[p.ID] == 1 {
+			text += "\n🏆 Поздравляем с победой!"
+		}
+		menu := &tb.ReplyMarkup{}
+		menu.Inline(menu.Row(menu.Data("Итоги турнира", fmt.Sprintf("pinfo_tournament%d", t.ID))))
+		for _, uid := range p.Roster {
+			b.notify(uid, text, menu)
+		}
+	}
+}
+
+// notifyApplication shows a new application right away to everyone who
+// reviews them.
+func (b *Bot) notifyApplication(app *domain.Application, admitted bool) {
+	t, err := b.svc.GetTournament(app.TournamentID)
+	if err != nil {
+		log.Printf("failed to notify about application: %v", err)
+		return
+	}
+
+	text := seatsText(t) + applicationText(t, app)
+	if admitted {
+		for _, uid := range t.StaffWith(domain.PermApplications) {
+			b.notify(uid, "✅ Новый участник, заявка одобрена автоматически.\n\n"+text)
+		}
+		return
+	}
+
+	menu := &tb.ReplyMarkup{}
+	btnApprove := menu.Data("✅ Принять", fmt.Sprintf("app_approve_%d_%d", app.TournamentID, app.TelegramUserID))
+	btnReject := menu.Data("❌ Отклонить", fmt.Sprintf("app_reject_%d_%d", app.TournamentID, app.TelegramUserID))
+	if app.Waitlisted {
+		text = "⏳ Новая заявка в лист ожидания.\n\n" + text
+		menu.Inline(menu.Row(btnReject))
+	} else {
+		text = "📥 Новая заявка!\n\n" + text
+		menu.Inline(menu.Row(btnApprove, btnReject))
+	}
+	for _, uid := range t.StaffWith(domain.PermApplications) {
+		b.notify(uid, text, menu)
+	}
+}
+
+// WaitlistPromoted tells the applicant a seat was freed for them.
+func (b *Bot) WaitlistPromoted(t *domain.Tournament, app *domain.Application) {
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data("Перейти к турниру", fmt.Sprintf("tournament_%d", t.ID))))
+	for _, uid := range app.Roster() {
+		b.notify(uid, fmt.Sprintf("🎉 В турнире «%s» освободилось место — вы больше не в листе ожидания и участвуете в турнире!", t.Title), menu)
+	}
+	for _, uid := range t.StaffWith(domain.PermApplications) {
+		b.notify(uid, fmt.Sprintf("⏳ %s переходит из листа ожидания в участники турнира «%s».", app.Name, t.Title))
+	}
+}
+
+// notifyRegistrationClosed tells the organizers that the registration
+// deadline of t has passed.
+func (b *Bot) notifyRegistrationClosed(t *domain.Tournament) {
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data("Перейти к турниру", fmt.Sprintf("tournament_%d", t.ID))))
+	for _, uid := range t.StaffWith(domain.PermSettings) {
+		b.notify(uid, fmt.Sprintf("⏰ Приём заявок на турнир «%s» закрыт по сроку.\n%s", t.Title, seatsText(t)), menu)
+	}
+}
@@ -0,0 +1,79 @@
+package bot
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+	tb "gopkg.in/telebot.v3"
+)
+
+func profileFieldName(f domain.ProfileField) string {
+	switch f {
+	case domain.ProfileDescription:
+		return "Описание"
+	case domain.ProfileRules:
+		return "Правила"
+	case domain.ProfilePrizes:
+		return "Призы"
+	case domain.ProfileLocation:
+		return "Место или ссылка"
+	}
+	return string(f)
+}
+
+// profileCard renders the tournament profile in Telegram HTML. Everything
+// typed by the organizers is escaped.
+func profileCard(t *domain.Tournament, loc *time.Location) string {
+	p := t.Profile
+	var text strings.Builder
+	fmt.Fprintf(&text, "🏆 <b>%s</b>\n", html.EscapeString(t.Title))
+	fmt.Fprintf(&text, "<i>%s · %s</i>\n", statusName(t.Status), systemName(t.System))
+	if p.Description != "" {
+		fmt.Fprintf(&text, "\n%s\n", html.EscapeString(p.Description))
+	}
+
+	text.WriteString("\n")
+	if t.Game != "" {
+		fmt.Fprintf(&text, "🎮 <b>Дисциплина:</b> %s\n", html.EscapeString(t.Game))
+	}
+	if p.StartsAt != nil {
+		fmt.Fprintf(&text, "📅 <b>Начало:</b> %s\n", formatTime(*p.StartsAt, loc))
+	}
+	if p.Location != "" {
+		fmt.Fprintf(&text, "📍 <b>Где:</b> %s\n", html.EscapeString(p.Location))
+	}
+	if t.Status.Preparing() {
+		text.WriteString("👥 " + seatsText(t))
+	}
+	if p.Prizes != "" {
+		fmt.Fprintf(&text, "\n🏅 <b>Призы</b>\n%s\n", html.EscapeString(p.Prizes))
+	}
+	if p.Rules != "" {
+		fmt.Fprintf(&text, "\n📜 <b>Правила</b>\n%s\n", html.EscapeString(p.Rules))
+	}
+	return text.String()
+}
+
+func aboutMenu(c tb.Context, t *domain.Tournament, loc *time.Location) error {
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))))
+	return c.Edit(profileCard(t, loc), menu, tb.ModeHTML)
+}
+
+func profileMenu(c tb.Context, t *domain.Tournament, loc *time.Location) error {
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+	for _, f := range domain.ProfileFields {
+		rows = append(rows, menu.Row(menu.Data("✏️ "+profileFieldName(f), fmt.Sprintf("profile_edit_%d_%s", t.ID, f))))
+	}
+	rows = append(rows, menu.Row(menu.Data("✏️ Дата начала", fmt.Sprintf("profile_start_%d", t.ID))))
+	if t.Profile.StartsAt != nil {
+		rows = append(rows, menu.Row(menu.Data("Убрать дату начала", fmt.Sprintf("profile_nostart_%d", t.ID))))
+	}
+	rows = append(rows, menu.Row(menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))))
+	menu.Inline(rows...)
+	return c.Edit(profileCard(t, loc)+"\n<i>Выберите, что изменить:</i>", menu, tb.ModeHTML)
+}
@@ -0,0 +1,38 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+)
+
+const ratingHistorySize = 10
+
+func gameName(game string) string {
+	if game == "" {
+		return "общий"
+	}
+	return game
+}
+
+func ratingText(ratings []*domain.PlayerRating, history []*domain.RatingChange) string {
+	if len(ratings) == 0 {
+		return fmt.Sprintf("У вас пока нет рейтинговых матчей. Начальный рейтинг — %d.", domain.DefaultRating)
+	}
+
+	var text strings.Builder
+	text.WriteString("📈 Ваш рейтинг:\n")
+	for _, r := range ratings {
+		fmt.Fprintf(&text, "%s: %d (матчей: %d)\n", gameName(r.Game), r.Rating, r.Matches)
+	}
+
+	if len(history) > 0 {
+		text.WriteString("\nПоследние изменения:\n")
+		for _, c := range history {
+			fmt.Fprintf(&text, "%s, %s, турнир %d, матч #%d: %d → %d (%+d)\n",
+				c.At.Format("02.01.2006"), gameName(c.Game), c.TournamentID, c.MatchID, c.Before, c.After, c.After-c.Before)
+		}
+	}
+	return text.String()
+}
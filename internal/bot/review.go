@@ -0,0 +1,298 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+	tb "gopkg.in/telebot.v3"
+)
+
+const appsPageSize = 8
+
+// reviewCtx keeps how an organizer looks through the applications of a
+// tournament and which of them they picked.
+type reviewCtx struct {
+	TournamentID domain.TournamentID
+	Page         int
+	Filter       domain.ApplicationFilter
+	Selected     map[domain.TelegramUserID]bool
+}
+
+func (rc *reviewCtx) clone() reviewCtx {
+	c := *rc
+	c.Selected = maps.Clone(rc.Selected)
+	return c
+}
+
+// updateReview changes the review of the tournament under the lock,
+// starting a new one if the organizer was looking at another tournament.
+// Updates run concurrently, so change must not keep rc.
+func (b *Bot) updateReview(uid domain.TelegramUserID, tID domain.TournamentID, change func(rc *reviewCtx)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.review == nil {
+		b.review = make(map[domain.TelegramUserID]*reviewCtx)
+	}
+	rc := b.review[uid]
+	if rc == nil || rc.TournamentID != tID {
+		rc = &reviewCtx{TournamentID: tID, Selected: make(map[domain.TelegramUserID]bool)}
+		b.review[uid] = rc
+	}
+	change(rc)
+}
+
+// reviewOf returns a copy of the review that is safe to read unlocked.
+func (b *Bot) reviewOf(uid domain.TelegramUserID, tID domain.TournamentID) reviewCtx {
+	var review reviewCtx
+	b.updateReview(uid, tID, func(rc *reviewCtx) {
+		review = rc.clone()
+	})
+	return review
+}
+
+func filterText(t *domain.Tournament, f domain.ApplicationFilter) string {
+	text := "сначала старые"
+	if f.NewestFirst {
+		text = "сначала новые"
+	}
+	if field := t.FindFormField(f.AnsweredField); field != nil {
+		text += fmt.Sprintf(", ответили на «%s»", field.Question)
+	}
+	return text
+}
+
+// nextAnsweredField cycles the filter through the optional questions of
+// the form, required ones are answered by everybody.
+func nextAnsweredField(t *domain.Tournament, current int) int {
+	var ids []int
+	for _, f := range t.Form {
+		if !f.Required {
+			ids = append(ids, f.ID)
+		}
+	}
+	for i, id := range ids {
+		if id == current && i+1 < len(ids) {
+			return ids[i+1]
+		}
+	}
+	if current == 0 && len(ids) > 0 {
+		return ids[0]
+	}
+	return 0
+}
+
+// showApplications lists a page of the applications with the filter of
+// the organizer's review.
+func (b *Bot) showApplications(c tb.Context, tID domain.TournamentID) error {
+	uid := domain.TelegramUserID(c.Sender().ID)
+	t, err := b.svc.GetManagedTournament(tID, uid, domain.PermApplications)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	all, err := b.svc.GetApplications(tID, uid)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+
+	menu := &tb.ReplyMarkup{}
+	btnBack := menu.Data("⬅️ Назад", fmt.Sprintf("tournament_%d", t.ID))
+	if len(all) == 0 {
+		menu.Inline(menu.Row(btnBack))
+		return c.Edit("Заявок нет\n"+seatsText(t), menu)
+	}
+
+	// applications that are gone are not selected any more
+	present := make(map[domain.TelegramUserID]bool)
+	for _, a := range all {
+		present[a.TelegramUserID] = true
+	}
+	var (
+		rc    reviewCtx
+		apps  []*domain.Application
+		pages int
+	)
+	b.updateReview(uid, tID, func(r *reviewCtx) {
+		for id := range r.Selected {
+			if !present[id] {
+				delete(r.Selected, id)
+			}
+		}
+		apps = r.Filter.Apply(all)
+		pages = (len(apps) + appsPageSize - 1) / appsPageSize
+		if r.Page >= pages {
+			r.Page = max(pages-1, 0)
+		}
+		rc = r.clone()
+	})
+	waitlist := domain.Waitlist(all)
+
+	var text strings.Builder
+	text.WriteString(seatsText(t))
+	fmt.Fprintf(&text, "Заявок на рассмотрении: %d, в листе ожидания: %d\n", len(all)-len(waitlist), len(waitlist))
+	fmt.Fprintf(&text, "Порядок: %s\n", filterText(t, rc.Filter))
+	if pages > 1 {
+		fmt.Fprintf(&text, "Страница %d из %d\n", rc.Page+1, pages)
+	}
+	text.WriteString("\nНажмите на заявку, чтобы выбрать её, или на 👁, чтобы открыть.")
+
+	var rows []tb.Row
+	from := rc.Page * appsPageSize
+	for _, a := range apps[from:min(from+appsPageSize, len(apps))] {
+		mark := "⬜"
+		if rc.Selected[a.TelegramUserID] {
+			mark = "☑️"
+		}
+		name := a.Name
+		if a.Waitlisted {
+			name = "⏳ " + name
+		}
+		rows = append(rows, menu.Row(
+			menu.Data(mark+" "+name, fmt.Sprintf("apps_sel_%d_%d", t.ID, a.TelegramUserID)),
+			menu.Data("👁", fmt.Sprintf("app_view_%d_%d", t.ID, a.TelegramUserID)),
+		))
+	}
+
+	var nav []tb.Btn
+	if rc.Page > 0 {
+		nav = append(nav, menu.Data("◀️", fmt.Sprintf("apps_page_%d_%d", t.ID, rc.Page-1)))
+	}
+	if rc.Page+1 < pages {
+		nav = append(nav, menu.Data("▶️", fmt.Sprintf("apps_page_%d_%d", t.ID, rc.Page+1)))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, menu.Row(nav...))
+	}
+
+	order := "⇅ Сначала новые"
+	if rc.Filter.NewestFirst {
+		order = "⇅ Сначала старые"
+	}
+	filterRow := []tb.Btn{menu.Data(order, fmt.Sprintf("apps_sort_%d", t.ID))}
+	if nextAnsweredField(t, 0) != 0 {
+		filterRow = append(filterRow, menu.Data("🔎 По ответу", fmt.Sprintf("apps_field_%d", t.ID)))
+	}
+	rows = append(rows, menu.Row(filterRow...))
+
+	var pending int
+	for _, a := range apps {
+		if !a.Waitlisted {
+			pending++
+		}
+	}
+	if pending > 0 {
+		rows = append(rows, menu.Row(menu.Data(fmt.Sprintf("✅ Принять все показанные (%d)", pending), fmt.Sprintf("apps_all_%d", t.ID))))
+	}
+	if n := len(rc.Selected); n > 0 {
+		rows = append(rows,
+			menu.Row(
+				menu.Data(fmt.Sprintf("✅ Принять выбранные (%d)", n), fmt.Sprintf("apps_approve_%d", t.ID)),
+				menu.Data(fmt.Sprintf("❌ Отклонить (%d)", n), fmt.Sprintf("apps_reject_%d", t.ID)),
+			),
+			menu.Row(menu.Data("Снять выбор", fmt.Sprintf("apps_clear_%d", t.ID))),
+		)
+	}
+	rows = append(rows, menu.Row(btnBack))
+	menu.Inline(rows...)
+	return c.Edit(text.String(), menu)
+}
+
+// reviewTargets returns whom a bulk action applies to: the picked
+// applications or all that match the filter, in the order of the list.
+func (b *Bot) reviewTargets(uid domain.TelegramUserID, tID domain.TournamentID, all bool) ([]domain.TelegramUserID, error) {
+	apps, err := b.svc.GetApplications(tID, uid)
+	if err != nil {
+		return nil, err
+	}
+	rc := b.reviewOf(uid, tID)
+
+	var ids []domain.TelegramUserID
+	for _, a := range rc.Filter.Apply(apps) {
+		if all && !a.Waitlisted || !all && rc.Selected[a.TelegramUserID] {
+			ids = append(ids, a.TelegramUserID)
+		}
+	}
+	return ids, nil
+}
+
+func (b *Bot) approveApplications(c tb.Context, tID domain.TournamentID, all bool) error {
+	uid := domain.TelegramUserID(c.Sender().ID)
+	ids, err := b.reviewTargets(uid, tID, all)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	if len(ids) == 0 {
+		return b.showApplications(c, tID)
+	}
+
+	approved, err := b.svc.ApproveApplications(tID, uid, ids)
+	if errors.Is(err, domain.ErrTournamentFull) {
+		return c.Send("Все места заняты. Увеличьте лимит участников в настройках регистрации.")
+	} else if errors.Is(err, domain.ErrApplicationNotFound) {
+		_ = c.Send("Часть заявок уже рассмотрена или отозвана, выберите заново.")
+		return b.showApplications(c, tID)
+	} else if err != nil {
+		return c.Send("Ошибка при одобрении: " + err.Error())
+	}
+
+	t, err := b.svc.GetTournament(tID)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	b.updateReview(uid, tID, func(rc *reviewCtx) {
+		for _, app := range approved {
+			delete(rc.Selected, app.TelegramUserID)
+		}
+	})
+	for _, app := range approved {
+		for _, member := range app.Roster() {
+			b.notify(member, fmt.Sprintf("✅ Ваша заявка на турнир «%s» принята!", t.Title))
+		}
+	}
+
+	text := fmt.Sprintf("✅ Принято заявок: %d.", len(approved))
+	if left := len(ids) - len(approved); left > 0 {
+		text += fmt.Sprintf(" Ещё %d не поместились: все места заняты.", left)
+	}
+	_ = c.Send(text)
+	return b.showApplications(c, tID)
+}
+
+func (b *Bot) rejectApplications(c tb.Context, tID domain.TournamentID) error {
+	uid := domain.TelegramUserID(c.Sender().ID)
+	ids, err := b.reviewTargets(uid, tID, false)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	if len(ids) == 0 {
+		return b.showApplications(c, tID)
+	}
+
+	rejected, err := b.svc.RejectApplications(tID, uid, ids)
+	if errors.Is(err, domain.ErrApplicationNotFound) {
+		_ = c.Send("Часть заявок уже рассмотрена или отозвана, выберите заново.")
+		return b.showApplications(c, tID)
+	} else if err != nil {
+		return c.Send("Ошибка при отклонении: " + err.Error())
+	}
+
+	t, err := b.svc.GetTournament(tID)
+	if err != nil {
+		return c.Send("Ошибка: " + err.Error())
+	}
+	b.updateReview(uid, tID, func(rc *reviewCtx) {
+		for _, app := range rejected {
+			delete(rc.Selected, app.TelegramUserID)
+		}
+	})
+	for _, app := range rejected {
+		for _, member := range app.Roster() {
+			b.notify(member, fmt.Sprintf("❌ Ваша заявка на турнир «%s» отклонена.", t.Title))
+		}
+	}
+
+	_ = c.Send(fmt.Sprintf("❌ Отклонено заявок: %d.", len(rejected)))
+	return b.showApplications(c, tID)
+}
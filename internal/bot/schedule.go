@@ -0,0 +1,132 @@
+package bot
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+	"github.com/Ycyken/tournament-bot/internal/service"
+	tb "gopkg.in/telebot.v3"
+)
+
+// timezones offered by the menu, any IANA name can be sent with /timezone
+var timezones = []struct {
+	Name     string
+	Location string
+}{
+	{"Калининград (UTC+2)", "Europe/Kaliningrad"},
+	{"Москва (UTC+3)", "Europe/Moscow"},
+	{"Самара (UTC+4)", "Europe/Samara"},
+	{"Екатеринбург (UTC+5)", "Asia/Yekaterinburg"},
+	{"Омск (UTC+6)", "Asia/Omsk"},
+	{"Новосибирск (UTC+7)", "Asia/Novosibirsk"},
+	{"Иркутск (UTC+8)", "Asia/Irkutsk"},
+	{"Якутск (UTC+9)", "Asia/Yakutsk"},
+	{"Владивосток (UTC+10)", "Asia/Vladivostok"},
+	{"UTC", "UTC"},
+}
+
+func timezoneMenu(loc *time.Location) (string, *tb.ReplyMarkup) {
+	menu := &tb.ReplyMarkup{}
+	var rows []tb.Row
+	for _, tz := range timezones {
+		rows = append(rows, menu.Row(menu.Data(tz.Name, "tz_set_"+tz.Location)))
+	}
+	rows = append(rows, menu.Row(menu.Data("Главное меню", MainMenu)))
+	menu.Inline(rows...)
+	return fmt.Sprintf("Ваш часовой пояс: %s\n\nВыберите другой или отправьте /timezone Europe/Moscow с названием пояса.", loc), menu
+}
+
+func (b *Bot) userLocation(uid domain.TelegramUserID) *time.Location {
+	loc, err := b.svc.GetUserLocation(uid)
+	if err != nil {
+		log.Printf("failed to get time zone of %d: %v", uid, err)
+		return time.Local
+	}
+	return loc
+}
+
+func formatTime(at time.Time, loc *time.Location) string {
+	return at.In(loc).Format(deadlineLayout) + " " + at.In(loc).Format("MST")
+}
+
+// scheduleText tells p when their match m is played or what was proposed.
+func scheduleText(m *domain.Match, p *domain.Participant, loc *time.Location) string {
+	switch {
+	case m.ProposedAt != nil && m.ProposedBy == p.ID:
+		return fmt.Sprintf("🕒 Вы предложили время: %s, ждём ответа соперника.\n", formatTime(*m.ProposedAt, loc))
+	case m.ProposedAt != nil:
+		return fmt.Sprintf("🕒 Соперник предлагает время: %s\n", formatTime(*m.ProposedAt, loc))
+	case m.ScheduledAt != nil:
+		return fmt.Sprintf("🕒 Матч назначен на %s\n", formatTime(*m.ScheduledAt, loc))
+	}
+	return ""
+}
+
+func scheduleRow(menu *tb.ReplyMarkup, t *domain.Tournament, m *domain.Match, p *domain.Participant) tb.Row {
+	btnPropose := menu.Data("🕒 Предложить время", fmt.Sprintf("sched_propose_%d_%d", t.ID, m.ID))
+	if m.ProposedAt != nil && m.ProposedBy != p.ID {
+		return menu.Row(menu.Data("✅ Принять время", fmt.Sprintf("sched_accept_%d_%d", t.ID, m.ID)), btnPropose)
+	}
+	return menu.Row(btnPropose)
+}
+
+func opponentOf(t *domain.Tournament, m *domain.Match, pID domain.ParticipantID) *domain.Participant {
+	if m.P1 == pID {
+		return t.FindParticipantByPID(m.P2)
+	}
+	return t.FindParticipantByPID(m.P1)
+}
+
+// notifyProposal asks the opponent of p to accept the proposed time.
+func (b *Bot) notifyProposal(t *domain.Tournament, m *domain.Match, p *domain.Participant) {
+	opp := opponentOf(t, m, p.ID)
+	for _, uid := range opp.Roster {
+		menu := &tb.ReplyMarkup{}
+		rows := []tb.Row{menu.Row(menu.Data("Мои матчи", fmt.Sprintf("pmatches_tournament%d_%d", t.ID, uid)))}
+		if t.CanReport(opp, uid) {
+			rows = append([]tb.Row{scheduleRow(menu, t, m, opp)}, rows...)
+		}
+		menu.Inline(rows...)
+		b.notify(uid, fmt.Sprintf("🕒 %s предлагает сыграть матч #%d турнира «%s» %s.",
+			p.Name, m.ID, t.Title, formatTime(*m.ProposedAt, b.userLocation(uid))), menu)
+	}
+}
+
+func (b *Bot) notifyScheduled(t *domain.Tournament, m *domain.Match) {
+	for _, id := range []domain.ParticipantID{m.P1, m.P2} {
+		opp := opponentOf(t, m, id)
+		for _, uid := range t.FindParticipantByPID(id).Roster {
+			b.notify(uid, fmt.Sprintf("✅ Матч #%d турнира «%s» против %s назначен на %s. Напомним заранее.",
+				m.ID, t.Title, opp.Name, formatTime(*m.ScheduledAt, b.userLocation(uid))))
+		}
+	}
+}
+
+func (b *Bot) notifyReminders(r *service.ReminderReport) {
+	t := r.Tournament
+	for _, m := range r.Matches {
+		left := time.Until(*m.ScheduledAt).Round(time.Minute)
+		for _, id := range []domain.ParticipantID{m.P1, m.P2} {
+			opp := opponentOf(t, m, id)
+			for _, uid := range t.FindParticipantByPID(id).Roster {
+				b.notify(uid, fmt.Sprintf("⏰ Напоминание: матч #%d турнира «%s» против %s%s в %s (через %s).",
+					m.ID, t.Title, opp.Name, participantTag(opp), formatTime(*m.ScheduledAt, b.userLocation(uid)), durationText(left)))
+			}
+		}
+	}
+}
+
+func durationText(d time.Duration) string {
+	h, m := int(d.Hours()), int(d.Minutes())%60
+	var parts []string
+	if h > 0 {
+		parts = append(parts, fmt.Sprintf("%d ч", h))
+	}
+	if m > 0 || h == 0 {
+		parts = append(parts, fmt.Sprintf("%d мин", m))
+	}
+	return strings.Join(parts, " ")
+}
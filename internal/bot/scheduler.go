@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+	"github.com/Ycyken/tournament-bot/internal/service"
+	tb "gopkg.in/telebot.v3"
+)
+
+const schedulerInterval = time.Minute
+
+// runScheduler applies expired round deadlines, closes registration
+// after its deadline and sends match reminders. Deadlines, registration
+// and reminders sent are all kept in Postgres, so whatever fell due while
+// the bot was down is handled on the first check after a restart.
+func (b *Bot) runScheduler() {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+	for {
+		reports, err := b.svc.ExpireDeadlines(time.Now())
+		if err != nil {
+			log.Printf("failed to expire deadlines: %v", err)
+		}
+		for _, r := range reports {
+			b.notifyDeadline(r)
+		}
+
+		closed, err := b.svc.CloseRegistrations(time.Now())
+		if err != nil {
+			log.Printf("failed to close registrations: %v", err)
+		}
+		for _, t := range closed {
+			b.notifyRegistrationClosed(t)
+		}
+
+		reminders, err := b.svc.DueReminders(time.Now())
+		if err != nil {
+			log.Printf("failed to send reminders: %v", err)
+		}
+		for _, r := range reminders {
+			b.notifyReminders(r)
+		}
+		<-ticker.C
+	}
+}
+
+func (b *Bot) notify(uid domain.TelegramUserID, text string, opts ...interface{}) {
+	if _, err := b.bot.Send(&tb.User{ID: int64(uid)}, text, opts...); err != nil {
+		log.Printf("failed to notify %d: %v", uid, err)
+	}
+}
+
+func (b *Bot) notifyDeadline(r *service.DeadlineReport) {
+	t := r.Tournament
+	for _, m := range r.Outcome.Forfeited {
+		for _, id := range []domain.ParticipantID{m.P1, m.P2} {
+			p := t.FindParticipantByPID(id)
+			for _, uid := range p.Roster {
+				b.notify(uid, fmt.Sprintf("⏰ Дедлайн раунда %d турнира «%s» истёк. Матч #%d засчитан техническим решением.\n\n%s",
+					r.Outcome.Round, t.Title, m.ID, t.GetMatchesHistory(p.ID)))
+			}
+		}
+	}
+
+	var text strings.Builder
+	fmt.Fprintf(&text, "⏰ Дедлайн раунда %d турнира «%s» истёк.\n", r.Outcome.Round, t.Title)
+	fmt.Fprintf(&text, "Засчитано техническим решением: %d\n", len(r.Outcome.Forfeited))
+	if len(r.Outcome.Escalated) > 0 {
+		text.WriteString("\nМатчи, которые нужно решить вам:\n")
+		for _, m := range r.Outcome.Escalated {
+			state := "не сыгран"
+			if m.State == domain.MatchConflicted {
+				state = "конфликт результатов"
+			}
+			fmt.Fprintf(&text, "• #%d: %s vs %s — %s\n", m.ID, t.FindParticipantByPID(m.P1).Name, t.FindParticipantByPID(m.P2).Name, state)
+		}
+	}
+	if errors.Is(r.DrawErr, domain.ErrNoLegalPairing) {
+		text.WriteString("\n⚠️ Следующий раунд невозможно составить: все оставшиеся пары уже играли друг с другом.")
+	}
+
+	menu := &tb.ReplyMarkup{}
+	menu.Inline(menu.Row(menu.Data("Текущие матчи", fmt.Sprintf("adm_matches_tournament%d", t.ID))))
+	for _, uid := range t.StaffWith(domain.PermResults) {
+		b.notify(uid, text.String(), menu)
+	}
+}
@@ -0,0 +1,92 @@
+package bot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+	tb "gopkg.in/telebot.v3"
+)
+
+// spectatorMenu is what users who neither run nor play in t see: the
+// public information and, while registration is open, a way to apply.
+func spectatorMenu(c tb.Context, t *domain.Tournament, app *domain.Application) error {
+	open := t.RegistrationOpen(time.Now())
+
+	var text strings.Builder
+	text.WriteString(TournamentInfoMessage(t))
+	switch {
+	case app != nil:
+		text.WriteString("\n📨 Ваша заявка на рассмотрении.")
+	case open:
+		text.WriteString("\n✅ Регистрация открыта.")
+	case t.Status.Preparing():
+		text.WriteString("\nРегистрация на турнир сейчас не идёт.")
+	}
+
+	menu := &tb.ReplyMarkup{}
+	rows := []tb.Row{menu.Row(menu.Data("📄 О турнире", fmt.Sprintf("about_tournament%d", t.ID)))}
+	if t.CurrentRound > 0 {
+		rows = append(rows, menu.Row(menu.Data(fmt.Sprintf("⚔️ Пары раунда %d", t.CurrentRound), fmt.Sprintf("spec_pairings_%d", t.ID))))
+	}
+	switch {
+	case app != nil:
+		rows = append(rows, menu.Row(menu.Data("✏️ Моя заявка", fmt.Sprintf("myapp_%d", t.ID))))
+	case open:
+		rows = append(rows, menu.Row(menu.Data("📨 Подать заявку", fmt.Sprintf("spec_apply_%d", t.ID))))
+	}
+	rows = append(rows, menu.Row(menu.Data("Главное меню", MainMenu)))
+	menu.Inline(rows...)
+	return c.Edit(text.String(), menu)
+}
+
+func matchResultText(t *domain.Tournament, m *domain.Match) string {
+	p1, p2 := t.FindParticipantByPID(m.P1).Name, t.FindParticipantByPID(m.P2).Name
+	if m.Result == nil {
+		return fmt.Sprintf("%s vs %s", p1, p2)
+	}
+	if *m.Result == domain.DoubleForfeit {
+		return fmt.Sprintf("%s vs %s — техническое поражение обоим", p1, p2)
+	}
+	var text string
+	if len(m.Games) > 0 {
+		s1, s2 := t.MatchScore(m)
+		text = fmt.Sprintf("%s %d:%d %s", p1, s1, s2, p2)
+	} else {
+		switch *m.Result {
+		case domain.P1Won:
+			text = fmt.Sprintf("%s 1:0 %s", p1, p2)
+		case domain.P2Won:
+			text = fmt.Sprintf("%s 0:1 %s", p1, p2)
+		default:
+			text = fmt.Sprintf("%s ½:½ %s", p1, p2)
+		}
+	}
+	if m.Forfeit {
+		text += " (без игры)"
+	}
+	return text
+}
+
+// pairingsText lists every match of the current round with its result,
+// if there is one yet.
+func pairingsText(t *domain.Tournament) string {
+	var lines []string
+	lines = append(lines, fmt.Sprintf("Пары турнира «%s» (Раунд %d):", t.Title, t.CurrentRound))
+	for _, m := range t.Matches[t.CurrentRound] {
+		var bracket string
+		if title := m.Bracket.Title(); title != "" {
+			bracket = " (" + title + ")"
+		}
+		mark := "🕓"
+		if m.State == domain.MatchCompleted {
+			mark = "✅"
+		}
+		lines = append(lines, fmt.Sprintf("%s #%d%s: %s", mark, m.ID, bracket, matchResultText(t, m)))
+	}
+	if len(lines) == 1 {
+		lines = append(lines, "— матчей нет.")
+	}
+	return strings.Join(lines, "\n")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/Ycyken/tournament-bot/internal/domain"
 )
@@ -11,19 +12,130 @@ import (
 func TournamentInfoMessage(t *domain.Tournament) string {
 	var text strings.Builder
 	fmt.Fprintf(&text, "🏆 Турнир %s (ID %d)\n", t.Title, t.ID)
+	fmt.Fprintf(&text, "Статус: %s\n", statusName(t.Status))
+	if t.Status.Preparing() {
+		text.WriteString(seatsText(t))
+		if t.RegistrationDeadline != nil {
+			fmt.Fprintf(&text, "Приём заявок до: %s\n", formatTime(*t.RegistrationDeadline, time.Local))
+		}
+	}
+	fmt.Fprintf(&text, "Система: %s\n", systemName(t.System))
+	fmt.Fprintf(&text, "Рейтинговая дисциплина: %s\n", gameName(t.Game))
+	if t.TeamMode {
+		fmt.Fprintf(&text, "Командный турнир\n")
+	}
+	if t.System == domain.RoundRobin && t.DoubleRoundRobin {
+		fmt.Fprintf(&text, "Играется в два круга\n")
+	}
+	fmt.Fprintf(&text, "Формат матчей: %s\n", bestOfName(t.BestOf))
 	fmt.Fprintf(&text, "Количество раундов: %d\n", t.LastRound)
 	fmt.Fprintf(&text, "Текущий раунд: %d\n\n", t.CurrentRound)
+	writeDrawSeed(&text, t)
+	if champion := t.Champion(); champion != nil {
+		fmt.Fprintf(&text, "Победитель: %s\n\n", champion.Name)
+	}
 	fmt.Fprintf(&text, "Участники:\n")
+	if t.CurrentRound > 0 && !t.System.Elimination() {
+		writeStandings(&text, t)
+		return text.String()
+	}
 	sort.Slice(t.Participants, func(i, j int) bool {
 		return t.Participants[i].Score > t.Participants[j].Score
 	})
 	for _, p := range t.Participants {
-		tag := ""
-		if p.TelegramTag != nil {
-			tag = " (@" + *p.TelegramTag + ")"
+		status := ""
+		if p.Withdrawn {
+			status = " — снялся"
+		} else if p.Eliminated {
+			status = " — выбыл"
 		}
-		fmt.Fprintf(&text, "%s%s: %.1f\n", p.Name, tag, p.Score)
+		fmt.Fprintf(&text, "%s%s%s: %.1f%s\n", p.Name, participantTag(p), participantRating(p), p.Score, status)
 	}
 
 	return text.String()
 }
+
+func writeStandings(text *strings.Builder, t *domain.Tournament) {
+	for _, s := range t.Standings() {
+		fmt.Fprintf(text, "%d. %s%s%s — %.1f", s.Rank, s.Participant.Name, participantTag(s.Participant), participantRating(s.Participant), s.Score)
+		if s.Participant.Withdrawn {
+			text.WriteString(" (снялся)")
+		}
+		for i, tb := range t.TieBreaks {
+			fmt.Fprintf(text, " | %s %g", tieBreakShortName(tb), s.TieBreaks[i])
+		}
+		text.WriteString("\n")
+	}
+	if len(t.TieBreaks) > 0 {
+		var names []string
+		for _, tb := range t.TieBreaks {
+			names = append(names, tieBreakShortName(tb)+" — "+tieBreakName(tb))
+		}
+		fmt.Fprintf(text, "\n%s\n", strings.Join(names, "; "))
+	}
+}
+
+// writeDrawSeed publishes the hash of the draw seed before the start and
+// reveals the seed itself afterwards, so the draw can be replayed.
+func writeDrawSeed(text *strings.Builder, t *domain.Tournament) {
+	if t.CurrentRound == 0 {
+		fmt.Fprintf(text, "🎲 Хэш зерна жеребьёвки (SHA-256): %s\nЗерно будет раскрыто при старте турнира.\n\n", t.DrawSeedHash())
+		return
+	}
+
+	check := "✅ посев воспроизводится из зерна"
+	if !t.VerifySeeding() {
+		check = "⚠️ посев не совпадает с зерном"
+	}
+	fmt.Fprintf(text, "🎲 Зерно жеребьёвки: %d\nSHA-256: %s\n%s\n\n", t.DrawSeed, t.DrawSeedHash(), check)
+}
+
+func participantTag(p *domain.Participant) string {
+	if p.TelegramTag == nil {
+		return ""
+	}
+	return " (@" + *p.TelegramTag + ")"
+}
+
+func participantRating(p *domain.Participant) string {
+	if p.Rating == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" [%d]", p.Rating)
+}
+
+func tieBreakName(tb domain.TieBreak) string {
+	switch tb {
+	case domain.Buchholz:
+		return "коэффициент Бухгольца"
+	case domain.MedianBuchholz:
+		return "усечённый Бухгольц"
+	case domain.SonnebornBerger:
+		return "коэффициент Зонненборна–Бергера"
+	case domain.Wins:
+		return "число побед"
+	case domain.DirectEncounter:
+		return "личная встреча"
+	case domain.GameDifference:
+		return "разница партий (или мячей)"
+	}
+	return string(tb)
+}
+
+func tieBreakShortName(tb domain.TieBreak) string {
+	switch tb {
+	case domain.Buchholz:
+		return "Бх"
+	case domain.MedianBuchholz:
+		return "УБх"
+	case domain.SonnebornBerger:
+		return "ЗБ"
+	case domain.Wins:
+		return "П"
+	case domain.DirectEncounter:
+		return "ЛВ"
+	case domain.GameDifference:
+		return "Р"
+	}
+	return string(tb)
+}
@@ -1,9 +1,85 @@
 package domain
 
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrApplicationNotFound = errors.New("application not found")
+	ErrEmptyName           = errors.New("name must not be empty")
+)
+
 type Application struct {
 	TournamentID   TournamentID
 	TelegramUserID TelegramUserID
 	Name           string
 	TelegramTag    *string
 	Text           *string
+	CreatedAt      time.Time
+	Waitlisted     bool           // applied when the tournament was full
+	Answers        map[int]string // by form field ID
+
+	// team mode: TelegramUserID is the captain, teammates join by the invite code
+	InviteCode string
+	Members    []TelegramUserID
+}
+
+// Roster lists the captain followed by the teammates who joined.
+func (a *Application) Roster() []TelegramUserID {
+	return append([]TelegramUserID{a.TelegramUserID}, a.Members...)
+}
+
+func (a *Application) Includes(uid TelegramUserID) bool {
+	for _, id := range a.Roster() {
+		if id == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// ApplicationFilter narrows down and orders applications under review.
+type ApplicationFilter struct {
+	NewestFirst   bool
+	AnsweredField int // only applications with an answer to this form field, 0 for all
+}
+
+// Apply returns the matching applications, oldest first unless NewestFirst.
+func (f ApplicationFilter) Apply(apps []*Application) []*Application {
+	var list []*Application
+	for _, a := range apps {
+		if f.AnsweredField != 0 && a.Answers[f.AnsweredField] == "" {
+			continue
+		}
+		list = append(list, a)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if f.NewestFirst {
+			return list[i].CreatedAt.After(list[j].CreatedAt)
+		}
+		return list[i].CreatedAt.Before(list[j].CreatedAt)
+	})
+	return list
+}
+
+type ApplicationStatus string
+
+const (
+	ApplicationPending  ApplicationStatus = "pending"
+	ApplicationApproved ApplicationStatus = "approved"
+	ApplicationRejected ApplicationStatus = "rejected"
+)
+
+// ApplicationRecord is an application as its applicant sees it among
+// their applications: pending or already decided.
+type ApplicationRecord struct {
+	TournamentID TournamentID
+	Tournament   string // title
+	Name         string
+	Status       ApplicationStatus
+	Waitlisted   bool
+	Reason       string // why it was rejected, if the organizer said
+	At           time.Time
 }
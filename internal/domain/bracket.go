@@ -0,0 +1,334 @@
+package domain
+
+const noParticipant ParticipantID = -1
+
+type sourceKind int
+
+const (
+	fromSeed sourceKind = iota
+	fromWinner
+	fromLoser
+)
+
+// bracketSource says where an entrant of a bracket node comes from:
+// a seed number or the winner/loser of another node.
+type bracketSource struct {
+	kind sourceKind
+	ref  int
+}
+
+type bracketNode struct {
+	slot    int
+	round   Round
+	bracket Bracket
+	in      [2]bracketSource
+
+	// resetOnly marks the second grand final, played only if the
+	// losers bracket champion wins the first one.
+	resetOnly bool
+}
+
+type nodeOutcome struct {
+	decided bool
+	winner  ParticipantID
+	loser   ParticipantID
+}
+
+// bracket is rebuilt from the bracket size on every use, so only seeds
+// and match results have to be persisted.
+type bracket struct {
+	t        *Tournament
+	nodes    []*bracketNode
+	outcomes map[int]nodeOutcome
+}
+
+func bracketSize(n int) int {
+	size := 1
+	for size < n {
+		size *= 2
+	}
+	return size
+}
+
+// seedPositions returns seeds in standard bracket order, e.g. 1 8 4 5 2 7 3 6,
+// so that the top seeds meet as late as possible and byes go to them.
+func seedPositions(size int) []int {
+	seeds := []int{1}
+	for len(seeds) < size {
+		next := make([]int, 0, len(seeds)*2)
+		for _, s := range seeds {
+			next = append(next, s, 2*len(seeds)+1-s)
+		}
+		seeds = next
+	}
+	return seeds
+}
+
+func (t *Tournament) bracket() *bracket {
+	b := &bracket{t: t, outcomes: make(map[int]nodeOutcome)}
+
+	size := bracketSize(len(t.Participants))
+	positions := seedPositions(size)
+
+	var upper Bracket
+	if t.System == DoubleElimination {
+		upper = WinnersBracket
+	}
+
+	var winners [][]*bracketNode
+	var prev []*bracketNode
+	for i := 0; i < size; i += 2 {
+		prev = append(prev, b.addNode(upper,
+			bracketSource{fromSeed, positions[i]},
+			bracketSource{fromSeed, positions[i+1]},
+		))
+	}
+	winners = append(winners, prev)
+	for len(prev) > 1 {
+		var cur []*bracketNode
+		for i := 0; i < len(prev); i += 2 {
+			cur = append(cur, b.addNode(upper,
+				bracketSource{fromWinner, prev[i].slot},
+				bracketSource{fromWinner, prev[i+1].slot},
+			))
+		}
+		winners = append(winners, cur)
+		prev = cur
+	}
+
+	if t.System == DoubleElimination {
+		b.addLosersBracket(winners)
+	}
+
+	return b
+}
+
+// addLosersBracket appends the losers bracket and the grand final.
+// Losers of winners round 1 play each other; losers of every later
+// winners round drop in against the survivors of the losers bracket,
+// in reversed order on every other round to postpone rematches.
+func (b *bracket) addLosersBracket(winners [][]*bracketNode) {
+	final := winners[len(winners)-1][0]
+	champion := bracketSource{fromLoser, final.slot}
+
+	if len(winners) > 1 {
+		var losers []*bracketNode
+		first := winners[0]
+		for i := 0; i < len(first); i += 2 {
+			losers = append(losers, b.addNode(LosersBracket,
+				bracketSource{fromLoser, first[i].slot},
+				bracketSource{fromLoser, first[i+1].slot},
+			))
+		}
+
+		for j := 1; j < len(winners); j++ {
+			drops := winners[j]
+			var cur []*bracketNode
+			for i, n := range losers {
+				drop := drops[i]
+				if j%2 == 1 {
+					drop = drops[len(drops)-1-i]
+				}
+				cur = append(cur, b.addNode(LosersBracket,
+					bracketSource{fromWinner, n.slot},
+					bracketSource{fromLoser, drop.slot},
+				))
+			}
+			losers = cur
+
+			if j == len(winners)-1 {
+				break
+			}
+			cur = nil
+			for i := 0; i < len(losers); i += 2 {
+				cur = append(cur, b.addNode(LosersBracket,
+					bracketSource{fromWinner, losers[i].slot},
+					bracketSource{fromWinner, losers[i+1].slot},
+				))
+			}
+			losers = cur
+		}
+		champion = bracketSource{fromWinner, losers[0].slot}
+	}
+
+	gf := b.addNode(GrandFinal, bracketSource{fromWinner, final.slot}, champion)
+	if b.t.BracketReset {
+		reset := b.addNode(GrandFinal, bracketSource{fromWinner, gf.slot}, bracketSource{fromLoser, gf.slot})
+		reset.resetOnly = true
+	}
+}
+
+func (b *bracket) addNode(br Bracket, in1, in2 bracketSource) *bracketNode {
+	n := &bracketNode{slot: len(b.nodes) + 1, bracket: br, in: [2]bracketSource{in1, in2}}
+	for _, in := range n.in {
+		if in.kind != fromSeed && b.node(in.ref).round+1 > n.round {
+			n.round = b.node(in.ref).round + 1
+		}
+	}
+	if n.round == 0 {
+		n.round = 1
+	}
+	b.nodes = append(b.nodes, n)
+	return n
+}
+
+func (b *bracket) node(slot int) *bracketNode {
+	return b.nodes[slot-1]
+}
+
+func (b *bracket) lastRound() Round {
+	var last Round
+	for _, n := range b.nodes {
+		if n.round > last {
+			last = n.round
+		}
+	}
+	return last
+}
+
+func (b *bracket) resolve(src bracketSource) (ParticipantID, bool) {
+	switch src.kind {
+	case fromSeed:
+		for _, p := range b.t.Participants {
+			if p.Seed == src.ref {
+				return p.ID, true
+			}
+		}
+		return noParticipant, true
+	case fromWinner:
+		o := b.outcome(src.ref)
+		return o.winner, o.decided
+	default:
+		o := b.outcome(src.ref)
+		return o.loser, o.decided
+	}
+}
+
+func (b *bracket) entrants(n *bracketNode) (ParticipantID, ParticipantID, bool) {
+	p1, ok1 := b.resolve(n.in[0])
+	p2, ok2 := b.resolve(n.in[1])
+	return p1, p2, ok1 && ok2
+}
+
+// resetSkipped reports whether the winners bracket champion already won
+// the first grand final, so the bracket reset is not played.
+func (b *bracket) resetSkipped(n *bracketNode) bool {
+	if !n.resetOnly {
+		return false
+	}
+	gf := b.t.findSlotMatch(n.in[0].ref)
+	return gf != nil && gf.State == MatchCompleted && gf.Result != nil && *gf.Result == P1Won
+}
+
+func (b *bracket) outcome(slot int) nodeOutcome {
+	if o, ok := b.outcomes[slot]; ok {
+		return o
+	}
+
+	o := nodeOutcome{winner: noParticipant, loser: noParticipant}
+	p1, p2, ok := b.entrants(b.node(slot))
+	switch {
+	case !ok:
+	case p1 == noParticipant:
+		o.decided, o.winner = true, p2
+	case p2 == noParticipant:
+		o.decided, o.winner = true, p1
+	case b.resetSkipped(b.node(slot)):
+		o.decided, o.winner, o.loser = true, p1, p2
+	default:
+		m := b.t.findSlotMatch(slot)
+		if m == nil || m.State != MatchCompleted || m.Result == nil {
+			break
+		}
+		o.decided = true
+		switch *m.Result {
+		case P1Won:
+			o.winner, o.loser = m.P1, m.P2
+		case P2Won:
+			o.winner, o.loser = m.P2, m.P1
+		default:
+			o.decided = false
+		}
+	}
+
+	b.outcomes[slot] = o
+	return o
+}
+
+// Champion returns the winner of the bracket final, or nil while it is not decided.
+func (t *Tournament) Champion() *Participant {
+	if !t.System.Elimination() || t.CurrentRound == 0 {
+		return nil
+	}
+	b := t.bracket()
+	o := b.outcome(b.nodes[len(b.nodes)-1].slot)
+	if !o.decided {
+		return nil
+	}
+	return t.FindParticipantByPID(o.winner)
+}
+
+func (t *Tournament) findSlotMatch(slot int) *Match {
+	for _, ms := range t.Matches {
+		for _, m := range ms {
+			if m.Slot == slot {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// markEliminated eliminates losers of the nodes whose loser does not go
+// on to another node, i.e. every loss in single elimination and the
+// second loss in double elimination.
+func (t *Tournament) markEliminated() {
+	b := t.bracket()
+	dropped := make(map[int]bool)
+	for _, n := range b.nodes {
+		for _, in := range n.in {
+			if in.kind == fromLoser {
+				dropped[in.ref] = true
+			}
+		}
+	}
+
+	for _, p := range t.Participants {
+		p.Eliminated = p.Withdrawn
+	}
+	for _, n := range b.nodes {
+		if dropped[n.slot] {
+			continue
+		}
+		if o := b.outcome(n.slot); o.decided && o.loser != noParticipant {
+			t.FindParticipantByPID(o.loser).Eliminated = true
+		}
+	}
+}
+
+func (t *Tournament) drawBracketRound() {
+	b := t.bracket()
+	start := t.CurrentRound
+	for t.CurrentRound < t.LastRound {
+		t.CurrentRound++
+		for _, n := range b.nodes {
+			if n.round != t.CurrentRound {
+				continue
+			}
+			p1, p2, ok := b.entrants(n)
+			if !ok || p1 == noParticipant || p2 == noParticipant || b.resetSkipped(n) {
+				continue
+			}
+			m := t.addMatch(t.CurrentRound, p1, p2)
+			m.Bracket = n.bracket
+			m.Slot = n.slot
+		}
+		if len(t.Matches[t.CurrentRound]) > 0 {
+			return
+		}
+	}
+
+	// nothing left to play, e.g. the bracket reset was not needed
+	t.CurrentRound = start
+	t.LastRound = start
+}
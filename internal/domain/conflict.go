@@ -0,0 +1,77 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrNotConflicted     = errors.New("match is not conflicted")
+	ErrUnknownResolution = errors.New("unknown resolution")
+)
+
+type Resolution string
+
+const (
+	AcceptP1    Resolution = "p1"   // the report of P1 stands
+	AcceptP2    Resolution = "p2"   // the report of P2 stands
+	BothForfeit Resolution = "both" // neither report is trusted, both sides lose
+)
+
+// Evidence is a comment or a photo a player attaches to a disputed match.
+type Evidence struct {
+	TournamentID TournamentID
+	MatchID      MatchID
+	UserID       TelegramUserID
+	Text         string
+	PhotoFileID  string
+	CreatedAt    time.Time
+}
+
+func (t *Tournament) FindMatch(matchID MatchID) *Match {
+	for _, ms := range t.Matches {
+		for _, m := range ms {
+			if m.ID == matchID {
+				return m
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveConflict settles a disputed match of the current round and
+// records who decided it and why.
+func (t *Tournament) ResolveConflict(matchID MatchID, resolution Resolution, note string, by TelegramUserID) error {
+	match := t.findRoundMatch(matchID)
+	if match == nil {
+		return ErrMatchNotFound
+	}
+	if match.State != MatchConflicted {
+		return ErrNotConflicted
+	}
+
+	switch resolution {
+	case AcceptP1:
+		match.Result, match.Games = match.OpinionP1, match.ClaimP1
+	case AcceptP2:
+		match.Result, match.Games = match.OpinionP2, match.ClaimP2
+	case BothForfeit:
+		if t.System.Elimination() {
+			return ErrDrawNotAllowed
+		}
+		result := DoubleForfeit
+		match.Result, match.Games = &result, nil
+		match.Forfeit = true
+	default:
+		return ErrUnknownResolution
+	}
+
+	match.Resolution = resolution
+	match.ResolutionNote = note
+	match.ResolvedBy = by
+	match.State = MatchCompleted
+	if err := t.DrawNewRound(); errors.Is(err, ErrNoLegalPairing) {
+		return err
+	}
+	return nil
+}
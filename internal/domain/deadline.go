@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrUnknownDeadlinePolicy = errors.New("unknown deadline policy")
+	ErrRoundOver             = errors.New("round is already over")
+)
+
+type DeadlinePolicy string
+
+const (
+	// DeadlineForfeit forfeits unreported matches: both sides lose if
+	// nobody reported. If only one side did, its report stands, a claimed
+	// win becoming a forfeit win. Conflicts and brackets, where somebody
+	// has to advance, still go to the organizer.
+	DeadlineForfeit DeadlinePolicy = "forfeit"
+	// DeadlineEscalate leaves every unfinished match to the organizer.
+	DeadlineEscalate DeadlinePolicy = "escalate"
+)
+
+type Deadline struct {
+	At      time.Time
+	Expired bool // the policy has already been applied
+}
+
+// DeadlineOutcome lists what happened to the unfinished matches of a
+// round when its deadline passed.
+type DeadlineOutcome struct {
+	Round     Round
+	Forfeited []*Match // decided by the deadline, including one-sided reports
+	Escalated []*Match
+}
+
+func (t *Tournament) SetDeadline(r Round, at time.Time) error {
+	if r < 1 || r < t.CurrentRound {
+		return ErrRoundOver
+	}
+	if t.Deadlines == nil {
+		t.Deadlines = make(map[Round]*Deadline)
+	}
+	t.Deadlines[r] = &Deadline{At: at}
+	return nil
+}
+
+// ExpireDeadline applies the deadline policy to the current round once its
+// deadline has passed. It returns nil if there is nothing to do.
+func (t *Tournament) ExpireDeadline(now time.Time) (*DeadlineOutcome, error) {
+	d := t.Deadlines[t.CurrentRound]
+	if t.CurrentRound == 0 || d == nil || d.Expired || now.Before(d.At) {
+		return nil, nil
+	}
+	d.Expired = true
+
+	outcome := &DeadlineOutcome{Round: t.CurrentRound}
+	for _, m := range t.Matches[t.CurrentRound] {
+		if m.State == MatchCompleted {
+			continue
+		}
+		if t.DeadlinePolicy != DeadlineForfeit || m.State == MatchConflicted {
+			outcome.Escalated = append(outcome.Escalated, m)
+			continue
+		}
+
+		var (
+			result ResultType
+			games  []GameScore
+			won    ResultType // what the reporting side claims for a win
+		)
+		switch {
+		case m.OpinionP1 != nil && m.OpinionP2 == nil:
+			result, games, won = *m.OpinionP1, m.ClaimP1, P1Won
+		case m.OpinionP2 != nil && m.OpinionP1 == nil:
+			result, games, won = *m.OpinionP2, m.ClaimP2, P2Won
+		default:
+			result = DoubleForfeit
+		}
+		if (result == DoubleForfeit || result == Draw) && t.System.Elimination() {
+			outcome.Escalated = append(outcome.Escalated, m)
+			continue
+		}
+
+		m.Result = &result
+		m.Games = nil
+		m.Forfeit = result == DoubleForfeit || result == won
+		if !m.Forfeit {
+			// the reporter admitted a loss or a draw, take it as played
+			m.Games = games
+		}
+		m.State = MatchCompleted
+		outcome.Forfeited = append(outcome.Forfeited, m)
+	}
+
+	if len(outcome.Forfeited) > 0 {
+		if err := t.DrawNewRound(); err != nil && !errors.Is(err, ErrNotAllMatchesCompleted) {
+			return outcome, err
+		}
+	}
+	return outcome, nil
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidField   = errors.New("a question needs text and a choice at least two options")
+	ErrUnknownField   = errors.New("unknown form field")
+	ErrInvalidAnswer  = errors.New("answer does not fit the question")
+	ErrAnswerRequired = errors.New("a required question is not answered")
+	ErrMustAgree      = errors.New("a required yes/no question has to be answered yes")
+)
+
+// MaxAnswerLength limits text answers, in characters.
+const MaxAnswerLength = 100
+
+type FieldKind string
+
+const (
+	FieldText   FieldKind = "text"
+	FieldNumber FieldKind = "number"
+	FieldChoice FieldKind = "choice"
+	FieldYesNo  FieldKind = "yesno"
+)
+
+var FieldKinds = []FieldKind{FieldText, FieldNumber, FieldChoice, FieldYesNo}
+
+// answers to yes/no questions as they are stored
+const (
+	AnswerYes = "yes"
+	AnswerNo  = "no"
+)
+
+// FormField is a question of the application form. A required yes/no
+// question is a confirmation, e.g. of the rules, and has to be answered yes.
+type FormField struct {
+	ID       int
+	Question string
+	Kind     FieldKind
+	Options  []string // choice only
+	Required bool
+}
+
+// Parse checks an answer to the field and returns it in the stored form:
+// numbers are normalized, a choice is the option itself and may be given
+// by its number.
+func (f *FormField) Parse(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		if f.Required {
+			return "", ErrAnswerRequired
+		}
+		return "", nil
+	}
+
+	switch f.Kind {
+	case FieldText:
+		if utf8.RuneCountInString(input) > MaxAnswerLength {
+			return "", ErrInvalidAnswer
+		}
+		return input, nil
+	case FieldNumber:
+		v, err := strconv.ParseFloat(strings.ReplaceAll(input, ",", "."), 64)
+		if err != nil {
+			return "", ErrInvalidAnswer
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case FieldChoice:
+		for _, o := range f.Options {
+			if strings.EqualFold(o, input) {
+				return o, nil
+			}
+		}
+		if i, err := strconv.Atoi(input); err == nil && i >= 1 && i <= len(f.Options) {
+			return f.Options[i-1], nil
+		}
+		return "", ErrInvalidAnswer
+	case FieldYesNo:
+		switch strings.ToLower(input) {
+		case AnswerYes, "y":
+			return AnswerYes, nil
+		case AnswerNo, "n":
+			if f.Required {
+				return "", ErrMustAgree
+			}
+			return AnswerNo, nil
+		}
+		return "", ErrInvalidAnswer
+	}
+	return "", ErrInvalidField
+}
+
+func (t *Tournament) FindFormField(id int) *FormField {
+	for _, f := range t.Form {
+		if f.ID == id {
+			return f
+		}
+	}
+	return nil
+}
+
+// AddFormField appends the question to the form and numbers it.
+func (t *Tournament) AddFormField(f *FormField) error {
+	f.Question = strings.TrimSpace(f.Question)
+	if f.Question == "" {
+		return ErrInvalidField
+	}
+
+	switch f.Kind {
+	case FieldChoice:
+		var options []string
+		for _, o := range f.Options {
+			if o = strings.TrimSpace(o); o != "" {
+				options = append(options, o)
+			}
+		}
+		if len(options) < 2 {
+			return ErrInvalidField
+		}
+		f.Options = options
+	case FieldText, FieldNumber, FieldYesNo:
+		f.Options = nil
+	default:
+		return ErrInvalidField
+	}
+
+	f.ID = 1
+	for _, other := range t.Form {
+		if other.ID >= f.ID {
+			f.ID = other.ID + 1
+		}
+	}
+	t.Form = append(t.Form, f)
+	return nil
+}
+
+func (t *Tournament) RemoveFormField(id int) error {
+	for i, f := range t.Form {
+		if f.ID == id {
+			t.Form = append(t.Form[:i], t.Form[i+1:]...)
+			return nil
+		}
+	}
+	return ErrUnknownField
+}
+
+// CheckAnswers validates the answers to the form and returns them in the
+// stored form. Answers to questions that are not in the form are dropped.
+func (t *Tournament) CheckAnswers(answers map[int]string) (map[int]string, error) {
+	checked := make(map[int]string)
+	for _, f := range t.Form {
+		v, err := f.Parse(answers[f.ID])
+		if err != nil {
+			return nil, err
+		}
+		if v != "" {
+			checked[f.ID] = v
+		}
+	}
+	return checked, nil
+}
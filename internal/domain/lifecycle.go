@@ -0,0 +1,69 @@
+package domain
+
+import "errors"
+
+var (
+	ErrInvalidTransition = errors.New("tournament cannot move to this state")
+	ErrRegistrationShut  = errors.New("registration is not open")
+	ErrNotRunning        = errors.New("tournament is not running")
+	ErrTournamentClosed  = errors.New("tournament is over and cannot be changed")
+)
+
+// Status is the stage of a tournament's lifecycle.
+type Status string
+
+const (
+	StatusDraft              Status = "draft"
+	StatusRegistration       Status = "registration"
+	StatusRegistrationClosed Status = "registration_closed"
+	StatusRunning            Status = "running"
+	StatusFinished           Status = "finished"
+	StatusCancelled          Status = "cancelled"
+	StatusArchived           Status = "archived"
+)
+
+var statusTransitions = map[Status][]Status{
+	StatusDraft:              {StatusRegistration, StatusCancelled},
+	StatusRegistration:       {StatusRegistrationClosed, StatusCancelled},
+	StatusRegistrationClosed: {StatusRegistration, StatusRunning, StatusCancelled},
+	StatusRunning:            {StatusFinished, StatusCancelled},
+	StatusFinished:           {StatusArchived},
+	StatusCancelled:          {StatusArchived},
+}
+
+func (s Status) CanMoveTo(to Status) bool {
+	for _, next := range statusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Preparing reports whether the tournament has not started yet.
+func (s Status) Preparing() bool {
+	return s == StatusDraft || s == StatusRegistration || s == StatusRegistrationClosed
+}
+
+// Over reports whether the tournament cannot be changed any more.
+func (s Status) Over() bool {
+	return s == StatusFinished || s == StatusCancelled || s == StatusArchived
+}
+
+// SetStatus moves the tournament to the next stage. The start goes through
+// Start, which also draws the first round.
+func (t *Tournament) SetStatus(to Status) error {
+	if to == StatusRunning || !t.Status.CanMoveTo(to) {
+		return ErrInvalidTransition
+	}
+	t.Status = to
+	return nil
+}
+
+// markFinished moves a running tournament to finished once its last match
+// is played.
+func (t *Tournament) markFinished() {
+	if t.Status == StatusRunning && t.Finished() {
+		t.Status = StatusFinished
+	}
+}
@@ -16,8 +16,30 @@ const (
 	P1Won ResultType = "p1"
 	P2Won ResultType = "p2"
 	Draw  ResultType = "draw"
+
+	// DoubleForfeit means both sides lost by forfeit, e.g. neither showed up.
+	DoubleForfeit ResultType = "both_lost"
+)
+
+type Bracket string
+
+const (
+	WinnersBracket Bracket = "winners"
+	LosersBracket  Bracket = "losers"
+	GrandFinal     Bracket = "grand_final"
 )
 
+var bracketTitles = map[Bracket]string{
+	WinnersBracket: "верхняя сетка",
+	LosersBracket:  "нижняя сетка",
+	GrandFinal:     "гранд-финал",
+}
+
+// Title is empty for matches outside a double elimination bracket.
+func (b Bracket) Title() string {
+	return bracketTitles[b]
+}
+
 type Match struct {
 	ID           MatchID
 	TournamentID TournamentID
@@ -26,10 +48,26 @@ type Match struct {
 	P1 ParticipantID
 	P2 ParticipantID
 
+	Bracket Bracket
+	Slot    int // node of the elimination bracket, 0 for Swiss
+
 	State     MatchState
 	OpinionP1 *ResultType
 	OpinionP2 *ResultType
 	Result    *ResultType
+	Games     []GameScore // empty when only the result is known
+	ClaimP1   []GameScore // scores reported by each side
+	ClaimP2   []GameScore
+	Forfeit   bool // the result was awarded without playing
+	Rated     bool // the result is already counted in player ratings
+
+	// set when the organizer settled a conflict
+	Resolution     Resolution
+	ResolutionNote string
+	ResolvedBy     TelegramUserID
 
 	ScheduledAt *time.Time
+	ProposedAt  *time.Time    // a time offered by one side, not accepted yet
+	ProposedBy  ParticipantID // who offered ProposedAt
+	Reminded    int           // how many of ReminderOffsets were already sent
 }
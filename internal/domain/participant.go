@@ -16,8 +16,12 @@ type Participant struct {
 	TournamentID TournamentID
 	Kind         ParticipantKind
 	Roster       []TelegramUserID
+	Captain      TelegramUserID
 
+	Seed       int
+	Rating     int
 	Eliminated bool
-	Score      float64 // for Swiss
+	Withdrawn  bool    // left the tournament or was removed by the organizer
+	Score      float64 // by the tournament's scoring rules
 	JoinedAt   time.Time
 }
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	ErrUnknownProfileField = errors.New("unknown profile field")
+	ErrProfileTooLong      = errors.New("text is too long")
+)
+
+// MaxProfileLength keeps the whole info card within one Telegram message.
+const MaxProfileLength = 600
+
+type ProfileField string
+
+const (
+	ProfileDescription ProfileField = "description"
+	ProfileRules       ProfileField = "rules"
+	ProfilePrizes      ProfileField = "prizes"
+	ProfileLocation    ProfileField = "location"
+)
+
+var ProfileFields = []ProfileField{ProfileDescription, ProfileRules, ProfilePrizes, ProfileLocation}
+
+// Profile is what the organizers tell about the tournament. Every field is
+// optional.
+type Profile struct {
+	Description string
+	Rules       string
+	Prizes      string
+	Location    string // a venue or a link for online tournaments
+	StartsAt    *time.Time
+}
+
+func (p *Profile) field(f ProfileField) *string {
+	switch f {
+	case ProfileDescription:
+		return &p.Description
+	case ProfileRules:
+		return &p.Rules
+	case ProfilePrizes:
+		return &p.Prizes
+	case ProfileLocation:
+		return &p.Location
+	}
+	return nil
+}
+
+func (p *Profile) Get(f ProfileField) string {
+	if v := p.field(f); v != nil {
+		return *v
+	}
+	return ""
+}
+
+// SetProfileField replaces one text of the profile, an empty value clears it.
+func (t *Tournament) SetProfileField(f ProfileField, value string) error {
+	v := t.Profile.field(f)
+	if v == nil {
+		return ErrUnknownProfileField
+	}
+	value = strings.TrimSpace(value)
+	if utf8.RuneCountInString(value) > MaxProfileLength {
+		return ErrProfileTooLong
+	}
+	*v = value
+	return nil
+}
+
+// SetStartDate announces when the tournament is going to start, nil removes
+// the date.
+func (t *Tournament) SetStartDate(at *time.Time, now time.Time) error {
+	if at != nil && !at.After(now) {
+		return ErrTimeInPast
+	}
+	t.Profile.StartsAt = at
+	return nil
+}
@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	DefaultRating = 1500
+	eloK          = 32
+)
+
+// PlayerRating is the Elo rating of a player in one game. Ratings are
+// kept per Telegram user, so they carry over between tournaments.
+type PlayerRating struct {
+	UserID  TelegramUserID
+	Game    string
+	Rating  int
+	Matches int
+}
+
+type RatingChange struct {
+	UserID       TelegramUserID
+	Game         string
+	TournamentID TournamentID
+	MatchID      MatchID
+	Before       int
+	After        int
+	At           time.Time
+}
+
+// EloDelta returns how much a player rated ra gains against one rated rb
+// after scoring score (1 for a win, 0.5 for a draw, 0 for a loss).
+func EloDelta(ra, rb int, score float64) int {
+	expected := 1 / (1 + math.Pow(10, float64(rb-ra)/400))
+	return int(math.Round(eloK * (score - expected)))
+}
+
+// RateMatches updates ratings with the completed matches that have not
+// been rated yet, in the order they were played, and marks them rated.
+// Forfeits are not rated. A team plays with the average rating of its
+// roster and every member gets the same change. Players missing from
+// ratings start at DefaultRating.
+func (t *Tournament) RateMatches(ratings map[TelegramUserID]*PlayerRating) []*RatingChange {
+	rating := func(uid TelegramUserID) *PlayerRating {
+		if ratings[uid] == nil {
+			ratings[uid] = &PlayerRating{UserID: uid, Game: t.Game, Rating: DefaultRating}
+		}
+		return ratings[uid]
+	}
+	average := func(p *Participant) int {
+		if len(p.Roster) == 0 {
+			return DefaultRating
+		}
+		var sum int
+		for _, uid := range p.Roster {
+			sum += rating(uid).Rating
+		}
+		return int(math.Round(float64(sum) / float64(len(p.Roster))))
+	}
+
+	var changes []*RatingChange
+	now := time.Now()
+	for r := Round(1); r <= t.CurrentRound; r++ {
+		for _, m := range t.Matches[r] {
+			if m.State != MatchCompleted || m.Result == nil || m.Rated || m.Forfeit {
+				continue
+			}
+			p1, p2 := t.FindParticipantByPID(m.P1), t.FindParticipantByPID(m.P2)
+			r1, r2 := average(p1), average(p2)
+			// Elo compares the result with an expected score between 0
+			// and 1, so it rates on the chess scale whatever the
+			// tournament's own scoring is.
+			deltas := map[*Participant]int{
+				p1: EloDelta(r1, r2, ChessScoring.Points(m, m.P1)),
+				p2: EloDelta(r2, r1, ChessScoring.Points(m, m.P2)),
+			}
+			for _, p := range []*Participant{p1, p2} {
+				for _, uid := range p.Roster {
+					pr := rating(uid)
+					changes = append(changes, &RatingChange{
+						UserID:       uid,
+						Game:         t.Game,
+						TournamentID: t.ID,
+						MatchID:      m.ID,
+						Before:       pr.Rating,
+						After:        pr.Rating + deltas[p],
+						At:           now,
+					})
+					pr.Rating += deltas[p]
+					pr.Matches++
+				}
+			}
+			m.Rated = true
+		}
+	}
+	return changes
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+var (
+	ErrTournamentFull   = errors.New("tournament is full")
+	ErrInvalidCapacity  = errors.New("capacity cannot be negative")
+	ErrCapacityTooSmall = errors.New("more participants are already approved")
+	ErrAlreadyStarted   = errors.New("tournament has already started")
+)
+
+// RegistrationOpen reports whether applications are taken at the moment.
+func (t *Tournament) RegistrationOpen(now time.Time) bool {
+	if t.Status != StatusRegistration {
+		return false
+	}
+	return t.RegistrationDeadline == nil || now.Before(*t.RegistrationDeadline)
+}
+
+// Full reports whether no more participants can be approved. A zero
+// Capacity means there is no limit.
+func (t *Tournament) Full() bool {
+	return t.Capacity > 0 && len(t.Participants) >= t.Capacity
+}
+
+func (t *Tournament) SetCapacity(n int) error {
+	if n < 0 {
+		return ErrInvalidCapacity
+	}
+	if n > 0 && n < len(t.Participants) {
+		return ErrCapacityTooSmall
+	}
+	t.Capacity = n
+	return nil
+}
+
+// SetRegistrationDeadline sets when registration closes, nil removes the
+// deadline.
+func (t *Tournament) SetRegistrationDeadline(at *time.Time, now time.Time) error {
+	if at != nil && !at.After(now) {
+		return ErrTimeInPast
+	}
+	t.RegistrationDeadline = at
+	return nil
+}
+
+// Leave takes the participant out of a tournament that has not started.
+// Unlike Withdraw nothing is left behind, the participant is listed in
+// Removed for the store to delete. The others keep their IDs.
+func (t *Tournament) Leave(pID ParticipantID) error {
+	if !t.Status.Preparing() {
+		return ErrAlreadyStarted
+	}
+	if t.FindParticipantByPID(pID) == nil {
+		return ErrUnknownParticipant
+	}
+
+	var rest []*Participant
+	for _, p := range t.Participants {
+		if p.ID != pID {
+			rest = append(rest, p)
+		}
+	}
+	t.Participants = rest
+	t.Removed = append(t.Removed, pID)
+	return nil
+}
+
+// NextParticipantID returns an ID nobody has, not even a participant who
+// left and is yet to be deleted.
+func (t *Tournament) NextParticipantID() ParticipantID {
+	var next ParticipantID
+	for _, p := range t.Participants {
+		next = max(next, p.ID+1)
+	}
+	for _, id := range t.Removed {
+		next = max(next, id+1)
+	}
+	return next
+}
+
+// Waitlist returns the waitlisted applications in the order they came in.
+func Waitlist(apps []*Application) []*Application {
+	var list []*Application
+	for _, a := range apps {
+		if a.Waitlisted {
+			list = append(list, a)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
@@ -0,0 +1,83 @@
+package domain
+
+import "errors"
+
+var (
+	ErrForbidden   = errors.New("not allowed to do this in the tournament")
+	ErrUnknownRole = errors.New("unknown role")
+	ErrOwnerInvite = errors.New("owner already manages the tournament")
+)
+
+// Role is what a user does in a tournament besides playing.
+type Role string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleOrganizer Role = "organizer"
+	RoleJudge     Role = "judge"
+)
+
+type Permission string
+
+const (
+	PermSettings     Permission = "settings"     // tournament settings, seeding and the start
+	PermApplications Permission = "applications" // approve and reject applications
+	PermResults      Permission = "results"      // set match results and resolve conflicts
+	PermParticipants Permission = "participants" // remove participants
+	PermStaff        Permission = "staff"        // invite and remove co-organizers and judges
+)
+
+// the owner may do everything
+var rolePermissions = map[Role][]Permission{
+	RoleOrganizer: {PermSettings, PermApplications, PermResults, PermParticipants},
+	RoleJudge:     {PermResults},
+}
+
+type StaffMember struct {
+	UserID TelegramUserID
+	Role   Role
+	Name   string
+}
+
+// RoleOf returns the role of uid in the tournament, empty for players and
+// strangers.
+func (t *Tournament) RoleOf(uid TelegramUserID) Role {
+	if uid == t.OwnerID {
+		return RoleOwner
+	}
+	for _, s := range t.Staff {
+		if s.UserID == uid {
+			return s.Role
+		}
+	}
+	return ""
+}
+
+func (t *Tournament) Can(uid TelegramUserID, perm Permission) bool {
+	role := t.RoleOf(uid)
+	if role == RoleOwner {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// StaffWith lists everyone allowed perm, the owner first.
+func (t *Tournament) StaffWith(perm Permission) []TelegramUserID {
+	ids := []TelegramUserID{t.OwnerID}
+	for _, s := range t.Staff {
+		if t.Can(s.UserID, perm) {
+			ids = append(ids, s.UserID)
+		}
+	}
+	return ids
+}
+
+func ValidStaffRole(r Role) bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
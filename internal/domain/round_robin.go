@@ -0,0 +1,71 @@
+package domain
+
+// bergerSchedule returns the pairings of positions 0..n-1 (n even) for
+// every round, built by the circle method behind Berger tables:
+// position n-1 stays in place while the others rotate around it.
+func bergerSchedule(n int) [][][2]int {
+	var rounds [][][2]int
+	for r := 0; r < n-1; r++ {
+		var pairs [][2]int
+		if r%2 == 0 {
+			pairs = append(pairs, [2]int{r, n - 1})
+		} else {
+			pairs = append(pairs, [2]int{n - 1, r})
+		}
+		for i := 1; i < n/2; i++ {
+			pairs = append(pairs, [2]int{(r + i) % (n - 1), (r - i + n - 1) % (n - 1)})
+		}
+		rounds = append(rounds, pairs)
+	}
+	return rounds
+}
+
+// scheduleRoundRobin creates the matches of all rounds at once. Positions
+// in the Berger table follow seeds; with an odd number of participants
+// the extra position is a bye, so everyone sits out once per cycle.
+func (t *Tournament) scheduleRoundRobin() {
+	n := len(t.Participants)
+	if n%2 == 1 {
+		n++
+	}
+
+	byPosition := make([]ParticipantID, n)
+	for i := range byPosition {
+		byPosition[i] = noParticipant
+	}
+	for _, p := range t.Participants {
+		byPosition[p.Seed-1] = p.ID
+	}
+
+	schedule := bergerSchedule(n)
+	cycles := 1
+	if t.DoubleRoundRobin {
+		cycles = 2
+	}
+
+	var round Round
+	for cycle := 0; cycle < cycles; cycle++ {
+		for _, pairs := range schedule {
+			round++
+			for _, pair := range pairs {
+				p1, p2 := byPosition[pair[0]], byPosition[pair[1]]
+				if p1 == noParticipant || p2 == noParticipant {
+					continue
+				}
+				if cycle == 1 {
+					p1, p2 = p2, p1
+				}
+				t.addMatch(round, p1, p2)
+			}
+		}
+	}
+	t.LastRound = round
+}
+
+func (t *Tournament) markRoundRobinByes() {
+	for _, p := range t.Participants {
+		if t.FindCurrentMatch(p.ID) == nil {
+			t.Byes[t.CurrentRound] = p.ID
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrTimeInPast    = errors.New("match time must be in the future")
+	ErrNoProposal    = errors.New("no match time was proposed")
+	ErrOwnProposal   = errors.New("the opponent has to accept the proposed time")
+	ErrMatchFinished = errors.New("match is already completed")
+)
+
+// ReminderOffsets say how long before a scheduled match players are
+// reminded of it, earliest first.
+var ReminderOffsets = []time.Duration{24 * time.Hour, time.Hour}
+
+// ProposeTime offers the opponent of pID to play the match at at. A new
+// proposal, e.g. a counter-proposal of the opponent, replaces the old one.
+func (t *Tournament) ProposeTime(matchID MatchID, pID ParticipantID, at, now time.Time) error {
+	match, err := t.schedulableMatch(matchID, pID)
+	if err != nil {
+		return err
+	}
+	if !at.After(now) {
+		return ErrTimeInPast
+	}
+	match.ProposedAt = &at
+	match.ProposedBy = pID
+	return nil
+}
+
+// AcceptTime agrees to the time the opponent of pID proposed.
+func (t *Tournament) AcceptTime(matchID MatchID, pID ParticipantID) error {
+	match, err := t.schedulableMatch(matchID, pID)
+	if err != nil {
+		return err
+	}
+	if match.ProposedAt == nil {
+		return ErrNoProposal
+	}
+	if match.ProposedBy == pID {
+		return ErrOwnProposal
+	}
+	match.ScheduledAt = match.ProposedAt
+	match.ProposedAt = nil
+	match.Reminded = 0
+	return nil
+}
+
+func (t *Tournament) schedulableMatch(matchID MatchID, pID ParticipantID) (*Match, error) {
+	match := t.findRoundMatch(matchID)
+	if match == nil {
+		return nil, ErrMatchNotFound
+	}
+	if match.P1 != pID && match.P2 != pID {
+		return nil, ErrParticipantNotInMatch
+	}
+	if match.State == MatchCompleted {
+		return nil, ErrMatchFinished
+	}
+	return match, nil
+}
+
+// DueReminders returns the scheduled matches that players should be
+// reminded of at now and marks the reminders sent. Reminders missed while
+// the bot was down are sent once, and none after the match has started;
+// marked lists every match whose counter moved, to be saved.
+func (t *Tournament) DueReminders(now time.Time) (due, marked []*Match) {
+	for _, m := range t.Matches[t.CurrentRound] {
+		if m.ScheduledAt == nil || m.State == MatchCompleted {
+			continue
+		}
+		remind := false
+		for m.Reminded < len(ReminderOffsets) && !now.Before(m.ScheduledAt.Add(-ReminderOffsets[m.Reminded])) {
+			m.Reminded++
+			remind = true
+		}
+		if !remind {
+			continue
+		}
+		marked = append(marked, m)
+		if now.Before(*m.ScheduledAt) {
+			due = append(due, m)
+		}
+	}
+	return due, marked
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidScore  = errors.New("invalid match score")
+	ErrInvalidBestOf = errors.New("best of must be between 1 and 9")
+)
+
+const MaxBestOf = 9
+
+// GameScore is the score of one game of a match, P1 first. In a best of 1
+// match it is the score of the match itself, e.g. goals.
+type GameScore struct {
+	P1 int
+	P2 int
+}
+
+// ParseScore reads games separated by spaces or commas, e.g. "3:1" or
+// "11:9, 8:11, 11:5".
+func ParseScore(s string) ([]GameScore, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == ';' })
+	if len(fields) == 0 {
+		return nil, ErrInvalidScore
+	}
+
+	games := make([]GameScore, len(fields))
+	for i, f := range fields {
+		a, b, ok := strings.Cut(f, ":")
+		if !ok {
+			a, b, ok = strings.Cut(f, "-")
+		}
+		p1, err1 := strconv.Atoi(a)
+		p2, err2 := strconv.Atoi(b)
+		if !ok || err1 != nil || err2 != nil || p1 < 0 || p2 < 0 {
+			return nil, ErrInvalidScore
+		}
+		games[i] = GameScore{P1: p1, P2: p2}
+	}
+	return games, nil
+}
+
+func FormatScore(games []GameScore) string {
+	parts := make([]string, len(games))
+	for i, g := range games {
+		parts[i] = fmt.Sprintf("%d:%d", g.P1, g.P2)
+	}
+	return strings.Join(parts, " ")
+}
+
+// SwapScore turns games around, so that a player can report the score
+// from their own side.
+func SwapScore(games []GameScore) []GameScore {
+	swapped := make([]GameScore, len(games))
+	for i, g := range games {
+		swapped[i] = GameScore{P1: g.P2, P2: g.P1}
+	}
+	return swapped
+}
+
+func sameScore(a, b []GameScore) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// BestOfRound returns the number of games a match of round r is played to.
+func (t *Tournament) BestOfRound(r Round) int {
+	if n := t.RoundBestOf[r]; n > 0 {
+		return n
+	}
+	if t.BestOf > 0 {
+		return t.BestOf
+	}
+	return 1
+}
+
+// SetBestOf sets the series length for round r, or the default one when r is 0.
+func (t *Tournament) SetBestOf(r Round, n int) error {
+	if n < 1 || n > MaxBestOf {
+		return ErrInvalidBestOf
+	}
+	if r == 0 {
+		t.BestOf = n
+		return nil
+	}
+	if t.RoundBestOf == nil {
+		t.RoundBestOf = make(map[Round]int)
+	}
+	t.RoundBestOf[r] = n
+	return nil
+}
+
+// scoreResult checks games against the match's best of and returns who
+// won. A best of 1 match takes a single score; a series is won by the
+// first to win the majority of games, and stops there. A tied series is
+// a draw, possible only when the number of games is even.
+func (t *Tournament) scoreResult(m *Match, games []GameScore) (ResultType, error) {
+	bestOf := t.BestOfRound(m.Round)
+	if len(games) == 0 || len(games) > bestOf {
+		return "", ErrInvalidScore
+	}
+	if bestOf == 1 {
+		return compareScore(games[0].P1, games[0].P2), nil
+	}
+
+	need := bestOf/2 + 1
+	var w1, w2 int
+	for i, g := range games {
+		if w1 == need || w2 == need {
+			return "", ErrInvalidScore // games after the series was decided
+		}
+		switch compareScore(g.P1, g.P2) {
+		case P1Won:
+			w1++
+		case P2Won:
+			w2++
+		default:
+			return "", ErrInvalidScore
+		}
+		if i == len(games)-1 && w1 < need && w2 < need && len(games) < bestOf {
+			return "", ErrInvalidScore // the series is not finished
+		}
+	}
+	return compareScore(w1, w2), nil
+}
+
+func compareScore(a, b int) ResultType {
+	switch {
+	case a > b:
+		return P1Won
+	case a < b:
+		return P2Won
+	}
+	return Draw
+}
+
+// MatchScore sums up the match for P1 and P2: the score itself in a best
+// of 1 match, the games won in a series.
+func (t *Tournament) MatchScore(m *Match) (int, int) {
+	if len(m.Games) == 1 && t.BestOfRound(m.Round) == 1 {
+		return m.Games[0].P1, m.Games[0].P2
+	}
+	var s1, s2 int
+	for _, g := range m.Games {
+		switch compareScore(g.P1, g.P2) {
+		case P1Won:
+			s1++
+		case P2Won:
+			s2++
+		}
+	}
+	return s1, s2
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "errors"
+
+var ErrInvalidScoringRules = errors.New("a win must score at least a draw and a draw at least a loss")
+
+type ScoringRules struct {
+	Win         float64
+	Draw        float64
+	Loss        float64
+	Bye         float64
+	ForfeitWin  float64
+	ForfeitLoss float64
+}
+
+var (
+	ChessScoring    = ScoringRules{Win: 1, Draw: 0.5, Loss: 0, Bye: 1, ForfeitWin: 1, ForfeitLoss: 0}
+	FootballScoring = ScoringRules{Win: 3, Draw: 1, Loss: 0, Bye: 3, ForfeitWin: 3, ForfeitLoss: 0}
+)
+
+// DefaultScoringRules gives a full point for a bye, except in round robin
+// where everyone sits out equally often and a bye scores nothing.
+func DefaultScoringRules(system System) ScoringRules {
+	rules := ChessScoring
+	if system == RoundRobin {
+		rules.Bye = 0
+	}
+	return rules
+}
+
+func (r ScoringRules) Validate() error {
+	if r.Win < r.Draw || r.Draw < r.Loss || r.ForfeitWin < r.ForfeitLoss {
+		return ErrInvalidScoringRules
+	}
+	return nil
+}
+
+// Points returns what pID scores for the completed match m.
+func (r ScoringRules) Points(m *Match, pID ParticipantID) float64 {
+	switch {
+	case *m.Result == Draw:
+		return r.Draw
+	case m.Forfeit && won(m, pID):
+		return r.ForfeitWin
+	case m.Forfeit:
+		return r.ForfeitLoss
+	case won(m, pID):
+		return r.Win
+	}
+	return r.Loss
+}
+
+func won(m *Match, pID ParticipantID) bool {
+	return *m.Result == P1Won && m.P1 == pID || *m.Result == P2Won && m.P2 == pID
+}
+
+func (t *Tournament) SetScoringRules(r ScoringRules) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	t.Scoring = r
+	t.recalculateScores()
+	return nil
+}
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+)
+
+var (
+	ErrUnknownParticipant = errors.New("unknown participant")
+	ErrDuplicateSeed      = errors.New("participant is listed more than once")
+)
+
+type SeedingMethod string
+
+const (
+	SeedingRandom    SeedingMethod = "random"
+	SeedingManual    SeedingMethod = "manual"
+	SeedingRating    SeedingMethod = "rating"
+	SeedingJoinOrder SeedingMethod = "join_order"
+)
+
+var SeedingMethods = []SeedingMethod{SeedingRandom, SeedingManual, SeedingRating, SeedingJoinOrder}
+
+// SetSeeds stores a manual seeding order; participants left out of it are
+// seeded after the listed ones in join order.
+func (t *Tournament) SetSeeds(order []ParticipantID) error {
+	seeds := make(map[ParticipantID]int)
+	for i, id := range order {
+		if t.FindParticipantByPID(id) == nil {
+			return ErrUnknownParticipant
+		}
+		if seeds[id] != 0 {
+			return ErrDuplicateSeed
+		}
+		seeds[id] = i + 1
+	}
+
+	for _, p := range t.Participants {
+		p.Seed = seeds[p.ID]
+	}
+	t.Seeding = SeedingManual
+	return nil
+}
+
+// seedParticipants numbers participants 1..N by the tournament's seeding
+// method, seed 1 being the strongest.
+func (t *Tournament) seedParticipants() {
+	ordered := make([]*Participant, len(t.Participants))
+	copy(ordered, t.Participants)
+
+	byJoinOrder := func(i, j int) bool {
+		if !ordered[i].JoinedAt.Equal(ordered[j].JoinedAt) {
+			return ordered[i].JoinedAt.Before(ordered[j].JoinedAt)
+		}
+		return ordered[i].ID < ordered[j].ID
+	}
+
+	switch t.Seeding {
+	case SeedingManual:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			si, sj := ordered[i].Seed, ordered[j].Seed
+			if (si == 0) != (sj == 0) {
+				return si != 0
+			}
+			if si != sj {
+				return si < sj
+			}
+			return byJoinOrder(i, j)
+		})
+	case SeedingRating:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			if ordered[i].Rating != ordered[j].Rating {
+				return ordered[i].Rating > ordered[j].Rating
+			}
+			return byJoinOrder(i, j)
+		})
+	case SeedingJoinOrder:
+		sort.SliceStable(ordered, byJoinOrder)
+	default:
+		ids := t.shuffledIndexes(len(t.Participants))
+		for i, idx := range ids {
+			ordered[i] = t.Participants[idx]
+		}
+	}
+
+	for i, p := range ordered {
+		p.Seed = i + 1
+	}
+}
+
+// bySeed returns participants still in the tournament ordered by seed.
+func (t *Tournament) bySeed() []*Participant {
+	var ps []*Participant
+	for _, p := range t.Participants {
+		if !p.Eliminated {
+			ps = append(ps, p)
+		}
+	}
+	sort.SliceStable(ps, func(i, j int) bool { return ps[i].Seed < ps[j].Seed })
+	return ps
+}
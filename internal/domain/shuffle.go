@@ -1,17 +1,61 @@
 package domain
 
 import (
+	crand "crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"math/rand"
+	"strconv"
 	"time"
 )
 
-func shuffledIDs(n int) []ParticipantID {
-	seed := time.Now().UnixNano()
-	rng := rand.New(rand.NewSource(seed))
+// NewRandSource builds the generator behind every random draw from the
+// tournament's draw seed. It is a variable so that it can be swapped.
+var NewRandSource = func(seed int64) rand.Source {
+	return rand.NewSource(seed)
+}
+
+// NewDrawSeed picks an unpredictable seed for a new tournament.
+var NewDrawSeed = func() int64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+	return int64(binary.BigEndian.Uint64(b[:]) >> 1)
+}
+
+// DrawSeedHash is the commitment published before the start: the SHA-256
+// of the decimal draw seed. Once the seed is revealed anyone can check it.
+func (t *Tournament) DrawSeedHash() string {
+	sum := sha256.Sum256([]byte(strconv.FormatInt(t.DrawSeed, 10)))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifySeeding replays the seeding from the draw seed and reports whether
+// it gives the seeds the participants actually have.
+func (t *Tournament) VerifySeeding() bool {
+	replay := &Tournament{Seeding: t.Seeding, DrawSeed: t.DrawSeed}
+	for _, p := range t.Participants {
+		copied := *p
+		replay.Participants = append(replay.Participants, &copied)
+	}
+	replay.seedParticipants()
+
+	for i, p := range replay.Participants {
+		if p.Seed != t.Participants[i].Seed {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Tournament) shuffledIndexes(n int) []int {
+	rng := rand.New(NewRandSource(t.DrawSeed))
 
-	ids := make([]ParticipantID, n)
+	ids := make([]int, n)
 	for i := 0; i < n; i++ {
-		ids[i] = ParticipantID(i)
+		ids[i] = i
 	}
 
 	rng.Shuffle(len(ids), func(i, j int) {
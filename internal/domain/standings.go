@@ -0,0 +1,202 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+)
+
+type TieBreak string
+
+const (
+	Buchholz        TieBreak = "buchholz"
+	MedianBuchholz  TieBreak = "median_buchholz"
+	SonnebornBerger TieBreak = "sonneborn_berger"
+	Wins            TieBreak = "wins"
+	DirectEncounter TieBreak = "direct_encounter"
+	GameDifference  TieBreak = "game_difference"
+)
+
+var AllTieBreaks = []TieBreak{Buchholz, MedianBuchholz, SonnebornBerger, Wins, DirectEncounter, GameDifference}
+
+func DefaultTieBreaks(system System) []TieBreak {
+	if system == RoundRobin {
+		return []TieBreak{DirectEncounter, SonnebornBerger, Wins}
+	}
+	return []TieBreak{Buchholz, MedianBuchholz, SonnebornBerger, Wins}
+}
+
+func ParseTieBreaks(s string) []TieBreak {
+	var tbs []TieBreak
+	for _, name := range strings.Split(s, ",") {
+		if name != "" {
+			tbs = append(tbs, TieBreak(name))
+		}
+	}
+	return tbs
+}
+
+func FormatTieBreaks(tbs []TieBreak) string {
+	names := make([]string, len(tbs))
+	for i, tb := range tbs {
+		names[i] = string(tb)
+	}
+	return strings.Join(names, ",")
+}
+
+type Standing struct {
+	Rank        int
+	Participant *Participant
+	Score       float64
+	TieBreaks   []float64 // in the order of Tournament.TieBreaks
+}
+
+// Standings ranks participants by score and then by the tournament's
+// tie-breaks in order. Participants still equal on everything share a rank.
+func (t *Tournament) Standings() []*Standing {
+	var matches []*Match
+	for r := Round(1); r <= t.CurrentRound; r++ {
+		for _, m := range t.Matches[r] {
+			if m.State == MatchCompleted && m.Result != nil {
+				matches = append(matches, m)
+			}
+		}
+	}
+
+	standings := make([]*Standing, len(t.Participants))
+	for i, p := range t.Participants {
+		standings[i] = &Standing{Participant: p, Score: p.Score, TieBreaks: make([]float64, len(t.TieBreaks))}
+	}
+	sort.SliceStable(standings, func(i, j int) bool {
+		return standings[i].Score > standings[j].Score
+	})
+
+	groups := splitStandings(standings, func(s *Standing) float64 { return s.Score })
+	for k, tb := range t.TieBreaks {
+		var next [][]*Standing
+		for _, group := range groups {
+			members := make(map[ParticipantID]bool)
+			for _, s := range group {
+				members[s.Participant.ID] = true
+			}
+			for _, s := range group {
+				s.TieBreaks[k] = t.tieBreakValue(tb, s.Participant.ID, matches, members)
+			}
+			sort.SliceStable(group, func(i, j int) bool {
+				return group[i].TieBreaks[k] > group[j].TieBreaks[k]
+			})
+			next = append(next, splitStandings(group, func(s *Standing) float64 { return s.TieBreaks[k] })...)
+		}
+		groups = next
+	}
+
+	standings = standings[:0]
+	for _, group := range groups {
+		rank := len(standings) + 1
+		for _, s := range group {
+			s.Rank = rank
+			standings = append(standings, s)
+		}
+	}
+	return standings
+}
+
+// FinalPlaces returns the place of every participant. In elimination
+// systems the champion is first and the rest are ranked by how late they
+// were knocked out, those leaving in the same round share the place.
+// Otherwise places follow the standings.
+func (t *Tournament) FinalPlaces() map[ParticipantID]int {
+	places := make(map[ParticipantID]int)
+	if !t.System.Elimination() {
+		for _, s := range t.Standings() {
+			places[s.Participant.ID] = s.Rank
+		}
+		return places
+	}
+
+	champion := t.Champion()
+	lasted := make(map[ParticipantID]Round)
+	for r := Round(1); r <= t.CurrentRound; r++ {
+		for _, m := range t.Matches[r] {
+			lasted[m.P1], lasted[m.P2] = r, r
+		}
+	}
+	if champion != nil {
+		lasted[champion.ID] = t.CurrentRound + 1
+	}
+	for _, p := range t.Participants {
+		places[p.ID] = 1
+		for _, q := range t.Participants {
+			if lasted[q.ID] > lasted[p.ID] {
+				places[p.ID]++
+			}
+		}
+	}
+	return places
+}
+
+func splitStandings(sorted []*Standing, key func(*Standing) float64) [][]*Standing {
+	var groups [][]*Standing
+	for i, s := range sorted {
+		if i == 0 || key(s) != key(sorted[i-1]) {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], s)
+	}
+	return groups
+}
+
+func opponent(m *Match, pID ParticipantID) ParticipantID {
+	if m.P1 == pID {
+		return m.P2
+	}
+	return m.P1
+}
+
+// tieBreakValue computes tb for pID; tied holds the participants still
+// tied with pID and is used by the direct encounter.
+func (t *Tournament) tieBreakValue(tb TieBreak, pID ParticipantID, matches []*Match, tied map[ParticipantID]bool) float64 {
+	var value float64
+	var oppScores []float64
+	for _, m := range matches {
+		if m.P1 != pID && m.P2 != pID {
+			continue
+		}
+		opp := opponent(m, pID)
+		oppScore := t.FindParticipantByPID(opp).Score
+		pts := t.Scoring.Points(m, pID)
+
+		switch tb {
+		case Buchholz:
+			value += oppScore
+		case MedianBuchholz:
+			oppScores = append(oppScores, oppScore)
+		case SonnebornBerger:
+			value += pts * oppScore
+		case Wins:
+			if won(m, pID) {
+				value++
+			}
+		case DirectEncounter:
+			if tied[opp] && len(tied) > 1 {
+				value += pts
+			}
+		case GameDifference:
+			s1, s2 := t.MatchScore(m)
+			if m.P2 == pID {
+				s1, s2 = s2, s1
+			}
+			value += float64(s1 - s2)
+		}
+	}
+
+	if tb == MedianBuchholz {
+		sort.Float64s(oppScores)
+		if len(oppScores) > 2 {
+			oppScores = oppScores[1 : len(oppScores)-1]
+		}
+		for _, s := range oppScores {
+			value += s
+		}
+	}
+	return value
+}
@@ -0,0 +1,160 @@
+package domain
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pairFirstRound pairs the top half of the seeds against the bottom half,
+// 1 vs N/2+1, 2 vs N/2+2 and so on, alternating sides. With an odd number
+// of players the lowest seed gets the bye.
+func (t *Tournament) pairFirstRound() ([][2]ParticipantID, ParticipantID) {
+	players := t.bySeed()
+	bye := noParticipant
+	if len(players)%2 == 1 {
+		bye = players[len(players)-1].ID
+		players = players[:len(players)-1]
+	}
+
+	half := len(players) / 2
+	var pairs [][2]ParticipantID
+	for i := 0; i < half; i++ {
+		top, bottom := players[i].ID, players[i+half].ID
+		if i%2 == 1 {
+			top, bottom = bottom, top
+		}
+		pairs = append(pairs, [2]ParticipantID{top, bottom})
+	}
+	return pairs, bye
+}
+
+// pairSwiss pairs players by score groups, Dutch style: the top half of a
+// group meets the bottom half, and whoever cannot be paired inside the
+// group floats down. The search backtracks, so it finds a pairing without
+// rematches whenever one exists. The bye goes to the lowest ranked player
+// who has not had one yet.
+func (t *Tournament) pairSwiss() ([][2]ParticipantID, ParticipantID, error) {
+	var players []*Participant
+	for _, p := range t.Participants {
+		if !p.Eliminated {
+			players = append(players, p)
+		}
+	}
+	sort.SliceStable(players, func(i, j int) bool {
+		if players[i].Score != players[j].Score {
+			return players[i].Score > players[j].Score
+		}
+		if players[i].Seed != players[j].Seed {
+			return players[i].Seed < players[j].Seed
+		}
+		return players[i].ID < players[j].ID
+	})
+
+	s := &swissPairer{t: t, failed: make(map[string]bool)}
+	if len(players)%2 == 0 {
+		pairs, ok := s.pair(players)
+		if !ok {
+			return nil, noParticipant, ErrNoLegalPairing
+		}
+		return pairs, noParticipant, nil
+	}
+
+	// a second bye is given only if nobody without one can sit out
+	for _, hadBye := range []bool{false, true} {
+		for i := len(players) - 1; i >= 0; i-- {
+			if t.hadBye(players[i].ID) != hadBye {
+				continue
+			}
+			rest := make([]*Participant, 0, len(players)-1)
+			rest = append(rest, players[:i]...)
+			rest = append(rest, players[i+1:]...)
+			if pairs, ok := s.pair(rest); ok {
+				return pairs, players[i].ID, nil
+			}
+		}
+	}
+	return nil, noParticipant, ErrNoLegalPairing
+}
+
+type swissPairer struct {
+	t      *Tournament
+	failed map[string]bool
+}
+
+// pair matches the top ranked player against the preferred opponents in
+// turn and recurses on the rest. Sets of players that cannot be paired
+// are remembered to keep the backtracking fast.
+func (s *swissPairer) pair(players []*Participant) ([][2]ParticipantID, bool) {
+	if len(players) == 0 {
+		return nil, true
+	}
+
+	key := pairingKey(players)
+	if s.failed[key] {
+		return nil, false
+	}
+
+	top := players[0]
+	for _, i := range preferredOpponents(players) {
+		opp := players[i]
+		if s.t.Opponents[top.ID][opp.ID] {
+			continue
+		}
+
+		rest := make([]*Participant, 0, len(players)-2)
+		for j, p := range players {
+			if j != 0 && j != i {
+				rest = append(rest, p)
+			}
+		}
+		if pairs, ok := s.pair(rest); ok {
+			return append([][2]ParticipantID{{top.ID, opp.ID}}, pairs...), true
+		}
+	}
+
+	s.failed[key] = true
+	return nil, false
+}
+
+// preferredOpponents orders the indexes of possible opponents for
+// players[0]: the player half a score group below first, then the rest of
+// the bottom half, then the top half upwards, then lower score groups.
+func preferredOpponents(players []*Participant) []int {
+	group := 1
+	for group < len(players) && players[group].Score == players[0].Score {
+		group++
+	}
+
+	var order []int
+	half := group / 2
+	if half > 0 {
+		for i := half; i < group; i++ {
+			order = append(order, i)
+		}
+		for i := half - 1; i >= 1; i-- {
+			order = append(order, i)
+		}
+	}
+	for i := group; i < len(players); i++ {
+		order = append(order, i)
+	}
+	return order
+}
+
+func pairingKey(players []*Participant) string {
+	ids := make([]string, len(players))
+	for i, p := range players {
+		ids[i] = strconv.FormatInt(int64(p.ID), 10)
+	}
+	return strings.Join(ids, ",")
+}
+
+func (t *Tournament) hadBye(pID ParticipantID) bool {
+	for _, id := range t.Byes {
+		if id == pID {
+			return true
+		}
+	}
+	return false
+}
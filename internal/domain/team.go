@@ -0,0 +1,43 @@
+package domain
+
+import (
+	crand "crypto/rand"
+	"errors"
+)
+
+var (
+	ErrNotCaptain         = errors.New("only the team captain can report match results")
+	ErrInviteNotFound     = errors.New("invite code not found")
+	ErrAlreadyParticipant = errors.New("user already in tournament")
+	ErrAlreadyApplied     = errors.New("user is already applied to this tournament")
+)
+
+const inviteCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// NewInviteCode generates the code teammates use to join a team's
+// application. Similar looking characters are left out. Unlike the draw
+// seed there is no fallback: a guessable code would let anyone join.
+var NewInviteCode = func() (string, error) {
+	b := make([]byte, 8)
+	if _, err := crand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = inviteCodeAlphabet[int(b[i])%len(inviteCodeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// CanReport reports whether uid may report results for p: the captain in
+// team mode unless every roster member is allowed to.
+func (t *Tournament) CanReport(p *Participant, uid TelegramUserID) bool {
+	if t.TeamMode && !t.RosterReports {
+		return p.Captain == uid
+	}
+	for _, id := range p.Roster {
+		if id == uid {
+			return true
+		}
+	}
+	return false
+}
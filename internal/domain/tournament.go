@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"sort"
 	"time"
 )
 
@@ -12,7 +11,10 @@ var (
 	ErrMatchNotFound          = errors.New("match not found")
 	ErrParticipantNotInMatch  = errors.New("participant is not in match")
 	ErrNotAllMatchesCompleted = errors.New("not all matches in current round are completed")
-	ErrSingleElimination      = errors.New("single elimination is not implemented yet")
+	ErrNotEnoughParticipants  = errors.New("need at least 2 participants to start tournament")
+	ErrUnknownSystem          = errors.New("unknown tournament system")
+	ErrDrawNotAllowed         = errors.New("draws are not allowed in elimination matches")
+	ErrNoLegalPairing         = errors.New("no pairing without rematches exists for the next round")
 )
 
 type System string
@@ -20,37 +22,76 @@ type Round int
 
 const (
 	SingleElimination System = "single_elimination"
+	DoubleElimination System = "double_elimination"
 	Swiss             System = "swiss"
+	RoundRobin        System = "round_robin"
 )
 
+func (s System) Elimination() bool {
+	return s == SingleElimination || s == DoubleElimination
+}
+
 type Tournament struct {
 	ID           TournamentID
 	OwnerID      TelegramUserID
+	Staff        []*StaffMember // co-organizers and judges
 	Title        string
+	Status       Status
+	Game         string // ratings are kept per game, empty for the general one
 	System       System
 	CurrentRound Round
 	LastRound    Round
 
+	BracketReset     bool // double elimination: replay the grand final if the losers bracket champion wins it
+	DoubleRoundRobin bool // round robin: play a second cycle with sides swapped
+	TeamMode         bool // participants are teams with a captain
+	RosterReports    bool // team mode: any roster member may report results, not only the captain
+	TieBreaks        []TieBreak
+	Scoring          ScoringRules
+	Seeding          SeedingMethod
+	DrawSeed         int64 // seeds the random draw, revealed once the tournament starts
+	BestOf           int   // games per match unless the round overrides it
+	RoundBestOf      map[Round]int
+	Deadlines        map[Round]*Deadline
+	DeadlinePolicy   DeadlinePolicy
+
+	Capacity             int          // most participants to approve, 0 for no limit
+	RegistrationDeadline *time.Time   // applications are not taken after it
+	AutoApprove          bool         // applications are approved as they come while there is room
+	Form                 []*FormField // questions of the application form
+
+	Profile Profile
+
 	Matches      map[Round][]*Match
 	Participants []*Participant
+	Removed      []ParticipantID // left before the start, deleted on save
 	Opponents    map[ParticipantID]map[ParticipantID]bool
-	Byes         map[ParticipantID]bool
+	Byes         map[Round]ParticipantID // who sits out each round
 
 	StartTime time.Time
 }
 
 func NewTournament(ownerID TelegramUserID, title string, system System) *Tournament {
 	return &Tournament{
-		OwnerID:      ownerID,
-		Title:        title,
-		System:       system,
-		CurrentRound: 0,
-		LastRound:    0,
+		OwnerID:        ownerID,
+		Status:         StatusDraft,
+		Title:          title,
+		System:         system,
+		CurrentRound:   0,
+		LastRound:      0,
+		TieBreaks:      DefaultTieBreaks(system),
+		Scoring:        DefaultScoringRules(system),
+		Seeding:        SeedingRandom,
+		DrawSeed:       NewDrawSeed(),
+		BestOf:         1,
+		RoundBestOf:    make(map[Round]int),
+		Deadlines:      make(map[Round]*Deadline),
+		DeadlinePolicy: DeadlineEscalate,
 
 		Matches:      make(map[Round][]*Match),
 		Participants: []*Participant{},
 		Opponents:    make(map[ParticipantID]map[ParticipantID]bool),
-		Byes:         make(map[ParticipantID]bool),
+		Byes:         make(map[Round]ParticipantID),
 
 		StartTime: time.Now(),
 	}
@@ -64,6 +105,52 @@ func (t *Tournament) UserParticipates(tgID TelegramUserID) bool {
 	return true
 }
 
+func (t *Tournament) Start() error {
+	participantsCount := len(t.Participants)
+	if participantsCount < 2 {
+		return ErrNotEnoughParticipants
+	}
+
+	t.seedParticipants()
+	switch t.System {
+	case Swiss:
+		// ⌊log₂(N)⌋ + 1
+		if participantsCount == 2 {
+			t.LastRound = 1
+		} else {
+			t.LastRound = Round(int(math.Log2(float64(participantsCount))) + 1)
+		}
+	case SingleElimination, DoubleElimination:
+		t.LastRound = t.bracket().lastRound()
+	case RoundRobin:
+		t.scheduleRoundRobin()
+	default:
+		return ErrUnknownSystem
+	}
+
+	t.Status = StatusRunning
+	return t.DrawNewRound()
+}
+
+// Finished reports whether the last match of the tournament is played.
+func (t *Tournament) Finished() bool {
+	if t.CurrentRound == 0 {
+		return false
+	}
+	if t.System.Elimination() {
+		return t.Champion() != nil
+	}
+	if t.CurrentRound < t.LastRound {
+		return false
+	}
+	for _, m := range t.Matches[t.CurrentRound] {
+		if m.State != MatchCompleted {
+			return false
+		}
+	}
+	return true
+}
+
 func (t *Tournament) FindCurrentMatch(pID ParticipantID) *Match {
 	for _, m := range t.Matches[t.CurrentRound] {
 		if m.P1 == pID || m.P2 == pID {
@@ -73,44 +160,142 @@ func (t *Tournament) FindCurrentMatch(pID ParticipantID) *Match {
 	return nil
 }
 
+func (t *Tournament) findRoundMatch(matchID MatchID) *Match {
+	for _, m := range t.Matches[t.CurrentRound] {
+		if m.ID == matchID {
+			return m
+		}
+	}
+	return nil
+}
+
 func (t *Tournament) ReportOpinion(matchID MatchID, pID ParticipantID, result ResultType) error {
-	match := t.Matches[t.CurrentRound][matchID]
+	match := t.findRoundMatch(matchID)
 	if match == nil {
 		return ErrMatchNotFound
 	}
+	if result == Draw && t.System.Elimination() {
+		return ErrDrawNotAllowed
+	}
+
+	return t.reportOpinion(match, pID, result, nil)
+}
 
+// ReportScore reports the score of the match, P1 first; the result
+// follows from the score.
+func (t *Tournament) ReportScore(matchID MatchID, pID ParticipantID, games []GameScore) error {
+	match := t.findRoundMatch(matchID)
+	if match == nil {
+		return ErrMatchNotFound
+	}
+	result, err := t.scoreResult(match, games)
+	if err != nil {
+		return err
+	}
+	if result == Draw && t.System.Elimination() {
+		return ErrDrawNotAllowed
+	}
+
+	return t.reportOpinion(match, pID, result, games)
+}
+
+// reportOpinion records one side's report. The match is completed once
+// both sides agree on the result and, if both gave one, on the score.
+func (t *Tournament) reportOpinion(match *Match, pID ParticipantID, result ResultType, games []GameScore) error {
 	switch pID {
 	case match.P1:
 		match.OpinionP1 = &result
+		match.ClaimP1 = games
 	case match.P2:
 		match.OpinionP2 = &result
+		match.ClaimP2 = games
 	default:
 		return ErrParticipantNotInMatch
 	}
 
 	if match.OpinionP1 != nil && match.OpinionP2 != nil {
-		if *match.OpinionP1 == *match.OpinionP2 {
+		agreed := *match.OpinionP1 == *match.OpinionP2
+		if match.ClaimP1 != nil && match.ClaimP2 != nil && !sameScore(match.ClaimP1, match.ClaimP2) {
+			agreed = false
+		}
+		if agreed {
 			match.Result = match.OpinionP1
+			match.Games = match.ClaimP1
+			if match.Games == nil {
+				match.Games = match.ClaimP2
+			}
 			match.State = MatchCompleted
 		} else {
 			match.State = MatchConflicted
 		}
 	}
 
-	_ = t.DrawNewRound()
+	if err := t.DrawNewRound(); errors.Is(err, ErrNoLegalPairing) {
+		return err
+	}
 
 	return nil
 }
 
 func (t *Tournament) SetMatchResultByAdmin(matchID MatchID, result ResultType) error {
-	match := t.Matches[t.CurrentRound][matchID]
+	match := t.findRoundMatch(matchID)
 	if match == nil {
 		return ErrMatchNotFound
 	}
+	if result == Draw && t.System.Elimination() {
+		return ErrDrawNotAllowed
+	}
+
+	match.Result = &result
+	match.Games = nil
+	match.Forfeit = false
+	match.State = MatchCompleted
+	if err := t.DrawNewRound(); errors.Is(err, ErrNoLegalPairing) {
+		return err
+	}
+	return nil
+}
+
+func (t *Tournament) SetMatchScoreByAdmin(matchID MatchID, games []GameScore) error {
+	match := t.findRoundMatch(matchID)
+	if match == nil {
+		return ErrMatchNotFound
+	}
+	result, err := t.scoreResult(match, games)
+	if err != nil {
+		return err
+	}
+	if result == Draw && t.System.Elimination() {
+		return ErrDrawNotAllowed
+	}
 
 	match.Result = &result
+	match.Games = games
+	match.Forfeit = false
 	match.State = MatchCompleted
-	_ = t.DrawNewRound()
+	if err := t.DrawNewRound(); errors.Is(err, ErrNoLegalPairing) {
+		return err
+	}
+	return nil
+}
+
+// ForfeitMatch completes the match as a win by forfeit for the side given by result.
+func (t *Tournament) ForfeitMatch(matchID MatchID, result ResultType) error {
+	match := t.findRoundMatch(matchID)
+	if match == nil {
+		return ErrMatchNotFound
+	}
+	if result == Draw {
+		return ErrDrawNotAllowed
+	}
+
+	match.Result = &result
+	match.Games = nil
+	match.Forfeit = true
+	match.State = MatchCompleted
+	if err := t.DrawNewRound(); errors.Is(err, ErrNoLegalPairing) {
+		return err
+	}
 	return nil
 }
 
@@ -156,15 +341,25 @@ func (t *Tournament) GetMatchesHistory(pID ParticipantID) string {
 			text += fmt.Sprintf("Раунд %d: у вас пока не назначен матч.\n\n", m.Round)
 			break
 		}
-		p := t.FindParticipantByPID(pID)
+		opponentID := m.P1
+		if opponentID == pID {
+			opponentID = m.P2
+		}
+		p := t.FindParticipantByPID(opponentID)
 		var tag string
 		if p.TelegramTag != nil {
 			tag = "(@" + *p.TelegramTag + ")"
 		}
-		text += fmt.Sprintf("Раунд %d: матч против %s%s:\n", m.Round, p.Name, tag)
+		var bracket string
+		if title := m.Bracket.Title(); title != "" {
+			bracket = " (" + title + ")"
+		}
+		text += fmt.Sprintf("Раунд %d%s: матч против %s%s:\n", m.Round, bracket, p.Name, tag)
 		text += fmt.Sprintf("Состояние: %s\n", m.State)
 		if m.Result != nil {
-			if m.P1 == pID && *m.Result == P1Won ||
+			if *m.Result == DoubleForfeit {
+				text += "Результат: поражение обоим."
+			} else if m.P1 == pID && *m.Result == P1Won ||
 				m.P2 == pID && *m.Result == P2Won {
 				text += "Результат: Вы выиграли!"
 			} else if *m.Result == "draw" {
@@ -172,6 +367,16 @@ func (t *Tournament) GetMatchesHistory(pID ParticipantID) string {
 			} else {
 				text += "Результат: Вы проиграли."
 			}
+			if len(m.Games) > 0 {
+				games := m.Games
+				if m.P2 == pID {
+					games = SwapScore(games)
+				}
+				text += " Счёт: " + FormatScore(games)
+			}
+			if m.Forfeit {
+				text += " (техническое решение)"
+			}
 		} else {
 			text += "Матч еще не завершен."
 		}
@@ -181,106 +386,125 @@ func (t *Tournament) GetMatchesHistory(pID ParticipantID) string {
 	return text
 }
 
+// DrawNewRound starts the next round once the current one is completed.
+// Matches of withdrawn participants are forfeited right after the draw,
+// which may complete the new round in turn.
 func (t *Tournament) DrawNewRound() error {
+	defer t.markFinished()
+	if err := t.drawNewRound(); err != nil {
+		return err
+	}
+	for t.forfeitWithdrawn() {
+		if err := t.drawNewRound(); err != nil {
+			if errors.Is(err, ErrNotAllMatchesCompleted) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Tournament) drawNewRound() error {
 	for _, m := range t.Matches[t.CurrentRound] {
 		if m.State != MatchCompleted {
 			return ErrNotAllMatchesCompleted
 		}
 	}
 
-	for _, m := range t.Matches[t.CurrentRound] {
-		switch *m.Result {
-		case P1Won:
-			t.Participants[m.P1].Score += 1.0
-		case P2Won:
-			t.Participants[m.P2].Score += 1.0
-		case Draw:
-			t.Participants[m.P1].Score += 0.5
-			t.Participants[m.P2].Score += 0.5
-		}
+	t.recalculateScores()
+	if t.System.Elimination() {
+		t.markEliminated()
 	}
 	if t.CurrentRound == t.LastRound {
 		return nil
 	}
-	t.CurrentRound++
+	if t.System.Elimination() {
+		t.drawBracketRound()
+		return nil
+	}
+	if t.System == RoundRobin {
+		t.CurrentRound++
+		// the whole schedule is created on start, so only the bye is left to
+		// record; it scores Scoring.Bye, which DefaultScoringRules leaves at
+		// zero because everyone sits out equally often
+		t.markRoundRobinByes()
+		return nil
+	}
 
-	pNumber := len(t.Participants)
-	var pairing []ParticipantID
-	if t.CurrentRound == 1 {
-		pairing = shuffledIDs(pNumber)
+	var pairs [][2]ParticipantID
+	bye := noParticipant
+	if t.CurrentRound == 0 {
+		pairs, bye = t.pairFirstRound()
 	} else {
-		pairing = pairParticipantsSwiss(t)
+		var err error
+		if pairs, bye, err = t.pairSwiss(); err != nil {
+			return err
+		}
 	}
-	for i := 0; i < len(pairing)-1; i += 2 {
-		t.Matches[t.CurrentRound] = append(t.Matches[t.CurrentRound], &Match{
-			ID:           t.nextMatchID(),
-			TournamentID: t.ID,
-			Round:        t.CurrentRound,
-			P1:           pairing[i],
-			P2:           pairing[i+1],
-			State:        MatchScheduled,
-		})
+
+	t.CurrentRound++
+	for _, pair := range pairs {
+		t.addMatch(t.CurrentRound, pair[0], pair[1])
 	}
-	if pNumber%2 == 1 {
-		t.Participants[pairing[pNumber-1]].Score += 1.0
-		t.Byes[pairing[pNumber-1]] = true
+	if bye != noParticipant {
+		t.Byes[t.CurrentRound] = bye
 	}
+	t.recalculateScores()
 
 	return nil
 }
 
-func (t *Tournament) nextMatchID() MatchID {
-	var matchID int
-	for _, ms := range t.Matches {
-		matchID += len(ms)
+func (t *Tournament) addMatch(round Round, p1, p2 ParticipantID) *Match {
+	m := &Match{
+		ID:           t.nextMatchID(),
+		TournamentID: t.ID,
+		Round:        round,
+		P1:           p1,
+		P2:           p2,
+		State:        MatchScheduled,
 	}
-	return MatchID(matchID)
-}
+	t.Matches[round] = append(t.Matches[round], m)
 
-func pairParticipantsSwiss(t *Tournament) []ParticipantID {
-	var byeID ParticipantID = -1
-	var lowestScore = math.MaxFloat64
-	for _, p := range t.Participants {
-		if !t.Byes[p.ID] && (p.Score < lowestScore) {
-			byeID = p.ID
-			lowestScore = p.Score
-		}
+	if t.Opponents[p1] == nil {
+		t.Opponents[p1] = make(map[ParticipantID]bool)
 	}
-	if len(t.Participants)%2 == 0 {
-		byeID = -1
+	if t.Opponents[p2] == nil {
+		t.Opponents[p2] = make(map[ParticipantID]bool)
 	}
+	t.Opponents[p1][p2] = true
+	t.Opponents[p2][p1] = true
 
-	participantsCopy := make([]Participant, len(t.Participants))
-	for i, p := range t.Participants {
-		participantsCopy[i] = *p
-	}
-	sort.Slice(participantsCopy, func(i, j int) bool {
-		return participantsCopy[i].Score > participantsCopy[j].Score
-	})
-
-	var pairing []ParticipantID
-	paired := make(map[ParticipantID]bool)
+	return m
+}
 
-	for i := 0; i < len(participantsCopy); i++ {
-		p1 := participantsCopy[i].ID
+// recalculateScores rebuilds scores from the results of played rounds,
+// so drawing a round may be retried without counting anything twice.
+func (t *Tournament) recalculateScores() {
+	for _, p := range t.Participants {
+		p.Score = 0
+	}
+	for _, pID := range t.Byes {
+		if p := t.FindParticipantByPID(pID); p != nil {
+			p.Score += t.Scoring.Bye
+		}
+	}
 
-		for j := i + 1; j < len(participantsCopy); j++ {
-			p2 := participantsCopy[j].ID
-			if p1 == byeID || p2 == byeID {
+	for r := Round(1); r <= t.CurrentRound; r++ {
+		for _, m := range t.Matches[r] {
+			if m.State != MatchCompleted || m.Result == nil {
 				continue
 			}
-			if !t.Opponents[p1][p2] && !paired[p2] {
-				pairing = append(pairing, p1, p2)
-				paired[p1] = true
-				paired[p2] = true
-				break
-			}
+			t.FindParticipantByPID(m.P1).Score += t.Scoring.Points(m, m.P1)
+			t.FindParticipantByPID(m.P2).Score += t.Scoring.Points(m, m.P2)
 		}
 	}
+}
 
-	if len(t.Participants)%2 == 1 {
-		pairing = append(pairing, byeID)
+func (t *Tournament) nextMatchID() MatchID {
+	var matchID int
+	for _, ms := range t.Matches {
+		matchID += len(ms)
 	}
-
-	return pairing
+	return MatchID(matchID)
 }
@@ -1,12 +1,17 @@
 package domain
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestTournament_DrawNewRound(t *testing.T) {
 	tourn := Tournament{
 		Matches:   make(map[Round][]*Match),
 		Opponents: make(map[ParticipantID]map[ParticipantID]bool),
-		Byes:      make(map[ParticipantID]bool),
+		Byes:      make(map[Round]ParticipantID),
 		LastRound: 5,
 	}
 
@@ -23,3 +28,1004 @@ func TestTournament_DrawNewRound(t *testing.T) {
 	}
 
 }
+
+func TestTournament_SingleElimination(t *testing.T) {
+	tourn := NewTournament(0, "knockout", SingleElimination)
+	for i := 0; i < 5; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if tourn.LastRound != 3 {
+		t.Fatalf("expected 3 rounds for 5 participants, got %d", tourn.LastRound)
+	}
+	// seeds 1, 2 and 3 get byes, so only 4 vs 5 is played in round 1
+	if len(tourn.Matches[1]) != 1 {
+		t.Fatalf("expected 1 match in round 1, got %d", len(tourn.Matches[1]))
+	}
+
+	for tourn.Champion() == nil {
+		round := tourn.CurrentRound
+		for _, m := range tourn.Matches[round] {
+			if err := tourn.SetMatchResultByAdmin(m.ID, P1Won); err != nil {
+				t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+			}
+		}
+		if tourn.Champion() == nil && tourn.CurrentRound == round {
+			t.Fatalf("round %d did not advance", round)
+		}
+	}
+
+	var eliminated int
+	for _, p := range tourn.Participants {
+		if p.Eliminated {
+			eliminated++
+		}
+	}
+	if eliminated != 4 {
+		t.Fatalf("expected 4 eliminated participants, got %d", eliminated)
+	}
+	if err := tourn.ReportOpinion(tourn.Matches[3][0].ID, tourn.Matches[3][0].P1, Draw); err != ErrDrawNotAllowed {
+		t.Fatalf("expected ErrDrawNotAllowed, got %v", err)
+	}
+}
+
+func TestTournament_DoubleElimination(t *testing.T) {
+	for _, tc := range []struct {
+		result  ResultType
+		matches int
+	}{
+		{P1Won, 10}, // winners bracket champion takes the grand final, no reset
+		{P2Won, 11}, // losers bracket champion wins, the grand final is replayed
+	} {
+		tourn := NewTournament(0, "double", DoubleElimination)
+		tourn.BracketReset = true
+		for i := 0; i < 6; i++ {
+			tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+		}
+		if err := tourn.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+
+		played := 0
+		for tourn.Champion() == nil {
+			round := tourn.CurrentRound
+			if len(tourn.Matches[round]) == 0 {
+				t.Fatalf("round %d has no matches", round)
+			}
+			for _, m := range tourn.Matches[round] {
+				if err := tourn.SetMatchResultByAdmin(m.ID, tc.result); err != nil {
+					t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+				}
+				played++
+			}
+		}
+
+		if played != tc.matches {
+			t.Fatalf("result %s: expected %d matches, got %d", tc.result, tc.matches, played)
+		}
+		for _, p := range tourn.Participants {
+			if p.Eliminated == (p == tourn.Champion()) {
+				t.Fatalf("result %s: participant %d eliminated = %v", tc.result, p.ID, p.Eliminated)
+			}
+			losses := 0
+			for _, ms := range tourn.Matches {
+				for _, m := range ms {
+					if m.P1 == p.ID && *m.Result == P2Won || m.P2 == p.ID && *m.Result == P1Won {
+						losses++
+					}
+				}
+			}
+			if p.Eliminated && losses != 2 {
+				t.Fatalf("result %s: participant %d eliminated after %d losses", tc.result, p.ID, losses)
+			}
+		}
+	}
+}
+
+func TestTournament_RoundRobin(t *testing.T) {
+	tourn := NewTournament(0, "league", RoundRobin)
+	tourn.DoubleRoundRobin = true
+	for i := 0; i < 5; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if tourn.LastRound != 10 {
+		t.Fatalf("expected 10 rounds for 5 participants in double round robin, got %d", tourn.LastRound)
+	}
+
+	sides := make(map[[2]ParticipantID]int)
+	for r := Round(1); r <= tourn.LastRound; r++ {
+		if len(tourn.Matches[r]) != 2 {
+			t.Fatalf("expected 2 matches in round %d, got %d", r, len(tourn.Matches[r]))
+		}
+		for _, m := range tourn.Matches[r] {
+			sides[[2]ParticipantID{m.P1, m.P2}]++
+		}
+	}
+	if len(sides) != 20 {
+		t.Fatalf("expected every ordered pair to play once, got %d distinct pairs", len(sides))
+	}
+
+	for tourn.CurrentRound < tourn.LastRound {
+		for _, m := range tourn.Matches[tourn.CurrentRound] {
+			if err := tourn.SetMatchResultByAdmin(m.ID, Draw); err != nil {
+				t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+			}
+		}
+	}
+	sat := make(map[ParticipantID]bool)
+	for _, p := range tourn.Byes {
+		sat[p] = true
+	}
+	if len(tourn.Byes) != int(tourn.LastRound) || len(sat) != 5 {
+		t.Fatalf("expected a bye every round and every participant to get one, got %v", tourn.Byes)
+	}
+
+	// each of the two byes scores only when the rules say so
+	base := make(map[ParticipantID]float64)
+	for _, p := range tourn.Participants {
+		base[p.ID] = p.Score
+	}
+	rules := tourn.Scoring
+	rules.Bye = 1
+	if err := tourn.SetScoringRules(rules); err != nil {
+		t.Fatalf("SetScoringRules() error = %v", err)
+	}
+	for _, p := range tourn.Participants {
+		if p.Score != base[p.ID]+2 {
+			t.Fatalf("participant %d: expected score %g, got %g", p.ID, base[p.ID]+2, p.Score)
+		}
+	}
+}
+
+func TestTournament_SwissNoRematches(t *testing.T) {
+	for _, n := range []int{4, 7, 15, 16} {
+		tourn := NewTournament(0, "swiss", Swiss)
+		for i := 0; i < n; i++ {
+			tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+		}
+		if err := tourn.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+
+		met := make(map[[2]ParticipantID]bool)
+		for {
+			round := tourn.CurrentRound
+			if len(tourn.Matches[round]) != n/2 {
+				t.Fatalf("n=%d: expected %d matches in round %d, got %d", n, n/2, round, len(tourn.Matches[round]))
+			}
+			for _, m := range tourn.Matches[round] {
+				pair := [2]ParticipantID{min(m.P1, m.P2), max(m.P1, m.P2)}
+				if met[pair] {
+					t.Fatalf("n=%d: rematch %v in round %d", n, pair, round)
+				}
+				met[pair] = true
+				if !tourn.Opponents[m.P1][m.P2] {
+					t.Fatalf("n=%d: opponents of match %d are not recorded", n, m.ID)
+				}
+			}
+			if round == tourn.LastRound {
+				break
+			}
+			for _, m := range tourn.Matches[round] {
+				if err := tourn.SetMatchResultByAdmin(m.ID, P1Won); err != nil {
+					t.Fatalf("n=%d: SetMatchResultByAdmin() error = %v", n, err)
+				}
+			}
+		}
+	}
+}
+
+func TestTournament_SwissNoLegalPairing(t *testing.T) {
+	tourn := NewTournament(0, "swiss", Swiss)
+	for i := 0; i < 2; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	tourn.LastRound = 2
+
+	if err := tourn.SetMatchResultByAdmin(tourn.Matches[1][0].ID, Draw); err != ErrNoLegalPairing {
+		t.Fatalf("expected ErrNoLegalPairing, got %v", err)
+	}
+	if tourn.CurrentRound != 1 {
+		t.Fatalf("expected to stay in round 1, got %d", tourn.CurrentRound)
+	}
+	for _, p := range tourn.Participants {
+		if p.Score != 0.5 {
+			t.Fatalf("expected score 0.5 after a draw, got %.1f", p.Score)
+		}
+	}
+}
+
+func TestTournament_Standings(t *testing.T) {
+	tourn := NewTournament(0, "league", RoundRobin)
+	tourn.TieBreaks = []TieBreak{DirectEncounter, SonnebornBerger, Wins}
+	for i := 0; i < 4; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+
+	// 0 and 1 finish on 2 points, 2 and 3 on 1; direct encounters favour 0 and 3
+	results := map[[2]ParticipantID]ResultType{
+		{0, 1}: P1Won, {0, 2}: P2Won, {0, 3}: P1Won,
+		{1, 2}: P1Won, {1, 3}: P1Won, {2, 3}: P2Won,
+	}
+	tourn.CurrentRound = 1
+	for pair, res := range results {
+		r := res
+		tourn.Matches[1] = append(tourn.Matches[1], &Match{P1: pair[0], P2: pair[1], State: MatchCompleted, Result: &r})
+	}
+	tourn.recalculateScores()
+
+	check := func(wantOrder []ParticipantID, wantRanks []int) {
+		t.Helper()
+		for i, s := range tourn.Standings() {
+			if s.Participant.ID != wantOrder[i] || s.Rank != wantRanks[i] {
+				t.Fatalf("tie-breaks %v: position %d is participant %d with rank %d, want %d with rank %d",
+					tourn.TieBreaks, i, s.Participant.ID, s.Rank, wantOrder[i], wantRanks[i])
+			}
+		}
+	}
+	check([]ParticipantID{0, 1, 3, 2}, []int{1, 2, 3, 4})
+
+	tourn.TieBreaks = nil
+	check([]ParticipantID{0, 1, 2, 3}, []int{1, 1, 3, 3})
+
+	// Sonneborn–Berger counts the points of the tournament's scoring: 0 beat 1 (6) and 3 (3)
+	if err := tourn.SetScoringRules(FootballScoring); err != nil {
+		t.Fatalf("SetScoringRules() error = %v", err)
+	}
+	tourn.TieBreaks = []TieBreak{SonnebornBerger}
+	if s := tourn.Standings()[0]; s.Participant.ID != 0 || s.TieBreaks[0] != 27 {
+		t.Fatalf("expected participant 0 first with Sonneborn–Berger 27, got %d with %g", s.Participant.ID, s.TieBreaks[0])
+	}
+}
+
+func TestTournament_ScoringRules(t *testing.T) {
+	tourn := NewTournament(0, "league", Swiss)
+	rules := FootballScoring
+	rules.Bye = 1
+	rules.ForfeitLoss = -1
+	if err := tourn.SetScoringRules(rules); err != nil {
+		t.Fatalf("SetScoringRules() error = %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	win, forfeit := tourn.Matches[1][0], tourn.Matches[1][1]
+	if err := tourn.SetMatchResultByAdmin(win.ID, P1Won); err != nil {
+		t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+	}
+	if err := tourn.ForfeitMatch(forfeit.ID, P2Won); err != nil {
+		t.Fatalf("ForfeitMatch() error = %v", err)
+	}
+
+	want := map[ParticipantID]float64{win.P1: 3, win.P2: 0, forfeit.P1: -1, forfeit.P2: 3}
+	for _, p := range tourn.Participants {
+		expected := want[p.ID]
+		for _, bye := range tourn.Byes { // in round 1 or in the freshly drawn round 2
+			if bye == p.ID {
+				expected += rules.Bye
+			}
+		}
+		if p.Score != expected {
+			t.Fatalf("participant %d: expected score %g, got %g", p.ID, expected, p.Score)
+		}
+	}
+
+	if err := tourn.SetScoringRules(ScoringRules{Win: 1, Draw: 2}); err != ErrInvalidScoringRules {
+		t.Fatalf("expected ErrInvalidScoringRules, got %v", err)
+	}
+}
+
+func TestTournament_Seeding(t *testing.T) {
+	knockout := NewTournament(0, "knockout", SingleElimination)
+	knockout.Seeding = SeedingRating
+	for i := 0; i < 8; i++ {
+		knockout.Participants = append(knockout.Participants, &Participant{ID: ParticipantID(i), Rating: 1000 + 100*i})
+	}
+	if err := knockout.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	// the highest rating is seed 1 and meets seed 8 in round 1
+	first := knockout.Matches[1][0]
+	if first.P1 != 7 || first.P2 != 0 {
+		t.Fatalf("expected seed 1 (7) vs seed 8 (0) in the first match, got %d vs %d", first.P1, first.P2)
+	}
+	for _, m := range knockout.Matches[1] {
+		winner := P1Won
+		if knockout.FindParticipantByPID(m.P2).Seed < knockout.FindParticipantByPID(m.P1).Seed {
+			winner = P2Won
+		}
+		if err := knockout.SetMatchResultByAdmin(m.ID, winner); err != nil {
+			t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+		}
+	}
+	for _, m := range knockout.Matches[2] {
+		if m.P1+m.P2 != 7+4 && m.P1+m.P2 != 6+5 {
+			t.Fatalf("expected seeds 1-4 and 2-3 in the semifinals, got %d vs %d", m.P1, m.P2)
+		}
+	}
+
+	swiss := NewTournament(0, "swiss", Swiss)
+	for i := 0; i < 5; i++ {
+		swiss.Participants = append(swiss.Participants, &Participant{ID: ParticipantID(i)})
+	}
+	if err := swiss.SetSeeds([]ParticipantID{4, 3, 2}); err != nil {
+		t.Fatalf("SetSeeds() error = %v", err)
+	}
+	if err := swiss.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	// seeds: 4, 3, 2, then 0 and 1 in join order; 1 is last and sits out
+	want := [][2]ParticipantID{{4, 2}, {0, 3}}
+	for i, m := range swiss.Matches[1] {
+		if m.P1 != want[i][0] || m.P2 != want[i][1] {
+			t.Fatalf("board %d: expected %v, got %d vs %d", i+1, want[i], m.P1, m.P2)
+		}
+	}
+	if bye, ok := swiss.Byes[1]; !ok || bye != 1 {
+		t.Fatalf("expected the lowest seed to get the bye")
+	}
+	if err := swiss.SetSeeds([]ParticipantID{1, 1}); err != ErrDuplicateSeed {
+		t.Fatalf("expected ErrDuplicateSeed, got %v", err)
+	}
+}
+
+func TestTournament_ReproducibleDraw(t *testing.T) {
+	seeds := func(drawSeed int64) []int {
+		tourn := NewTournament(0, "swiss", Swiss)
+		tourn.DrawSeed = drawSeed
+		for i := 0; i < 9; i++ {
+			tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i)})
+		}
+		if err := tourn.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		if !tourn.VerifySeeding() {
+			t.Fatalf("seeding does not replay from draw seed %d", drawSeed)
+		}
+
+		var s []int
+		for _, p := range tourn.Participants {
+			s = append(s, p.Seed)
+		}
+		return s
+	}
+
+	first, second := seeds(42), seeds(42)
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("same draw seed gave different seeds: %v and %v", first, second)
+		}
+	}
+
+	tourn := NewTournament(0, "swiss", Swiss)
+	tourn.DrawSeed = 42
+	if tourn.DrawSeedHash() != "73475cb40a568e8da8a045ced110137e159f890ac4da883b6b17dc651b3a8049" {
+		t.Fatalf("unexpected draw seed hash %s", tourn.DrawSeedHash())
+	}
+}
+
+func TestTournament_RateMatches(t *testing.T) {
+	tourn := NewTournament(0, "ladder", Swiss)
+	for i := 0; i < 4; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	win, forfeit := tourn.Matches[1][0], tourn.Matches[1][1]
+	ratings := map[TelegramUserID]*PlayerRating{
+		TelegramUserID(win.P1): {UserID: TelegramUserID(win.P1), Rating: 1600, Matches: 10},
+	}
+	if err := tourn.ForfeitMatch(forfeit.ID, P1Won); err != nil {
+		t.Fatalf("ForfeitMatch() error = %v", err)
+	}
+	if err := tourn.SetMatchResultByAdmin(win.ID, P2Won); err != nil {
+		t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+	}
+
+	changes := tourn.RateMatches(ratings)
+	if len(changes) != 2 {
+		t.Fatalf("expected 2 rating changes, forfeits are not rated, got %d", len(changes))
+	}
+	// the upset costs the favourite more than an even game would
+	if got := ratings[TelegramUserID(win.P1)].Rating; got != 1580 {
+		t.Fatalf("expected the favourite to drop to 1580, got %d", got)
+	}
+	if got := ratings[TelegramUserID(win.P2)].Rating; got != DefaultRating+20 {
+		t.Fatalf("expected the underdog to rise to %d, got %d", DefaultRating+20, got)
+	}
+	if ratings[TelegramUserID(win.P1)].Matches != 11 {
+		t.Fatalf("expected the played match to be counted")
+	}
+
+	if changes := tourn.RateMatches(ratings); len(changes) != 0 {
+		t.Fatalf("expected rated matches to be skipped, got %d changes", len(changes))
+	}
+}
+
+func TestTournament_MatchScores(t *testing.T) {
+	tourn := NewTournament(0, "cup", Swiss)
+	tourn.TieBreaks = []TieBreak{GameDifference}
+	if err := tourn.SetBestOf(0, 3); err != nil {
+		t.Fatalf("SetBestOf() error = %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	agreed, disputed := tourn.Matches[1][0], tourn.Matches[1][1]
+	games, err := ParseScore("11:9, 8:11 11:5")
+	if err != nil {
+		t.Fatalf("ParseScore() error = %v", err)
+	}
+	if err := tourn.ReportScore(agreed.ID, agreed.P1, games[:2]); err != ErrInvalidScore {
+		t.Fatalf("expected ErrInvalidScore for an unfinished series, got %v", err)
+	}
+	if err := tourn.ReportScore(agreed.ID, agreed.P1, append(games, GameScore{1, 0})); err != ErrInvalidScore {
+		t.Fatalf("expected ErrInvalidScore for a game after the series was won, got %v", err)
+	}
+	for _, p := range []ParticipantID{agreed.P1, agreed.P2} {
+		if err := tourn.ReportScore(agreed.ID, p, games); err != nil {
+			t.Fatalf("ReportScore() error = %v", err)
+		}
+	}
+	if agreed.State != MatchCompleted || *agreed.Result != P1Won || FormatScore(agreed.Games) != "11:9 8:11 11:5" {
+		t.Fatalf("expected the agreed score to complete the match, got %s %v", agreed.State, agreed.Games)
+	}
+
+	if err := tourn.ReportScore(disputed.ID, disputed.P1, []GameScore{{0, 11}, {0, 11}}); err != nil {
+		t.Fatalf("ReportScore() error = %v", err)
+	}
+	if err := tourn.ReportScore(disputed.ID, disputed.P2, []GameScore{{0, 11}, {5, 11}, {0, 11}}); err != ErrInvalidScore {
+		t.Fatalf("expected ErrInvalidScore, got %v", err)
+	}
+	if err := tourn.ReportScore(disputed.ID, disputed.P2, []GameScore{{0, 11}, {11, 5}, {0, 11}}); err != nil {
+		t.Fatalf("ReportScore() error = %v", err)
+	}
+	if disputed.State != MatchConflicted {
+		t.Fatalf("expected different scores to conflict, got %s", disputed.State)
+	}
+
+	if err := tourn.SetMatchScoreByAdmin(disputed.ID, []GameScore{{0, 11}, {0, 11}}); err != nil {
+		t.Fatalf("SetMatchScoreByAdmin() error = %v", err)
+	}
+	for _, s := range tourn.Standings() {
+		var want float64
+		switch s.Participant.ID {
+		case agreed.P1:
+			want = 1
+		case agreed.P2:
+			want = -1
+		case disputed.P1:
+			want = -2
+		case disputed.P2:
+			want = 2
+		}
+		if s.TieBreaks[0] != want {
+			t.Fatalf("participant %d: expected game difference %g, got %g", s.Participant.ID, want, s.TieBreaks[0])
+		}
+	}
+}
+
+func TestTournament_CanReport(t *testing.T) {
+	tourn := NewTournament(0, "teams", Swiss)
+	team := &Participant{ID: 0, Kind: ParticipantKindTeam, Roster: []TelegramUserID{10, 11, 12}, Captain: 10}
+
+	if !tourn.CanReport(team, 11) || tourn.CanReport(team, 20) {
+		t.Fatalf("outside team mode any roster member and nobody else may report")
+	}
+
+	tourn.TeamMode = true
+	if !tourn.CanReport(team, 10) || tourn.CanReport(team, 11) {
+		t.Fatalf("in team mode only the captain may report")
+	}
+
+	tourn.RosterReports = true
+	if !tourn.CanReport(team, 12) {
+		t.Fatalf("expected roster members to report when allowed")
+	}
+}
+
+func TestTournament_Withdraw(t *testing.T) {
+	tourn := NewTournament(0, "open", Swiss)
+	for i := 0; i < 4; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	quit, played := tourn.Matches[1][0], tourn.Matches[1][1]
+	if err := tourn.Withdraw(quit.P1); err != nil {
+		t.Fatalf("Withdraw() error = %v", err)
+	}
+	if quit.State != MatchCompleted || !quit.Forfeit || *quit.Result != P2Won {
+		t.Fatalf("expected the pending match to be lost by forfeit, got %s", quit.State)
+	}
+	if err := tourn.Withdraw(quit.P1); err != ErrAlreadyWithdrawn {
+		t.Fatalf("expected ErrAlreadyWithdrawn, got %v", err)
+	}
+
+	if err := tourn.SetMatchResultByAdmin(played.ID, P1Won); err != nil {
+		t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+	}
+	if tourn.CurrentRound != 2 || len(tourn.Matches[2]) != 1 {
+		t.Fatalf("expected one match in round 2, got %d", len(tourn.Matches[2]))
+	}
+	if m := tourn.FindCurrentMatch(quit.P1); m != nil {
+		t.Fatalf("withdrawn participant was paired in round 2")
+	}
+	if len(tourn.Standings()) != 4 {
+		t.Fatalf("expected the withdrawn participant to stay in the standings")
+	}
+
+	rr := NewTournament(0, "league", RoundRobin)
+	for i := 0; i < 4; i++ {
+		rr.Participants = append(rr.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := rr.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	// the second withdrawal meets the first only in the last round
+	var both *Match
+	for _, m := range rr.Matches[rr.LastRound] {
+		if m.P1 == 0 || m.P2 == 0 {
+			both = m
+		}
+	}
+	if err := rr.Withdraw(both.P1); err != nil {
+		t.Fatalf("Withdraw() error = %v", err)
+	}
+	if err := rr.Withdraw(both.P2); err != nil {
+		t.Fatalf("Withdraw() error = %v", err)
+	}
+	for rr.CurrentRound < rr.LastRound {
+		for _, m := range rr.Matches[rr.CurrentRound] {
+			if m.State != MatchCompleted {
+				if err := rr.SetMatchResultByAdmin(m.ID, Draw); err != nil {
+					t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+				}
+			}
+		}
+	}
+	for _, ms := range rr.Matches {
+		for _, m := range ms {
+			if (m.P1 == 0 || m.P2 == 0) && (!m.Forfeit || m.State != MatchCompleted) {
+				t.Fatalf("expected every match of the withdrawn participant to be forfeited")
+			}
+		}
+	}
+	if *both.Result != DoubleForfeit {
+		t.Fatalf("expected both withdrawn participants to lose their match, got %s", *both.Result)
+	}
+}
+
+func TestTournament_ExpireDeadline(t *testing.T) {
+	tourn := NewTournament(0, "blitz", Swiss)
+	tourn.DeadlinePolicy = DeadlineForfeit
+	for i := 0; i < 8; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	deadline := time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC)
+	if err := tourn.SetDeadline(1, deadline); err != nil {
+		t.Fatalf("SetDeadline() error = %v", err)
+	}
+	if err := tourn.SetDeadline(0, deadline); err != ErrRoundOver {
+		t.Fatalf("expected ErrRoundOver, got %v", err)
+	}
+	silent, oneSided, conflicted, conceded := tourn.Matches[1][0], tourn.Matches[1][1], tourn.Matches[1][2], tourn.Matches[1][3]
+	if err := tourn.ReportOpinion(oneSided.ID, oneSided.P2, P2Won); err != nil {
+		t.Fatalf("ReportOpinion() error = %v", err)
+	}
+	if err := tourn.ReportOpinion(conceded.ID, conceded.P1, P2Won); err != nil {
+		t.Fatalf("ReportOpinion() error = %v", err)
+	}
+	if err := tourn.ReportOpinion(conflicted.ID, conflicted.P1, P1Won); err != nil {
+		t.Fatalf("ReportOpinion() error = %v", err)
+	}
+	if err := tourn.ReportOpinion(conflicted.ID, conflicted.P2, P2Won); err != nil {
+		t.Fatalf("ReportOpinion() error = %v", err)
+	}
+
+	if outcome, _ := tourn.ExpireDeadline(deadline.Add(-time.Minute)); outcome != nil {
+		t.Fatalf("expected nothing to happen before the deadline")
+	}
+	outcome, err := tourn.ExpireDeadline(deadline)
+	if err != nil {
+		t.Fatalf("ExpireDeadline() error = %v", err)
+	}
+	if *silent.Result != DoubleForfeit || *oneSided.Result != P2Won || !oneSided.Forfeit {
+		t.Fatalf("expected a double forfeit and a one-sided forfeit, got %s and %s", *silent.Result, *oneSided.Result)
+	}
+	if *conceded.Result != P2Won || conceded.Forfeit {
+		t.Fatalf("expected the admitted loss to stand as played, got %s", *conceded.Result)
+	}
+	if len(outcome.Forfeited) != 3 || len(outcome.Escalated) != 1 || outcome.Escalated[0] != conflicted {
+		t.Fatalf("expected the conflict to go to the organizer")
+	}
+	if outcome, _ := tourn.ExpireDeadline(deadline.Add(time.Hour)); outcome != nil {
+		t.Fatalf("expected the deadline to be applied only once")
+	}
+
+	if err := tourn.SetMatchResultByAdmin(conflicted.ID, P1Won); err != nil {
+		t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+	}
+	for _, p := range []ParticipantID{silent.P1, silent.P2, oneSided.P1} {
+		if score := tourn.FindParticipantByPID(p).Score; score != 0 {
+			t.Fatalf("participant %d: expected no points after a forfeit loss, got %g", p, score)
+		}
+	}
+	if score := tourn.FindParticipantByPID(oneSided.P2).Score; score != 1 {
+		t.Fatalf("expected the reporting side to get a forfeit win, got %g", score)
+	}
+	if score := tourn.FindParticipantByPID(conceded.P2).Score; score != 1 {
+		t.Fatalf("expected the silent side to get the win the other side admitted, got %g", score)
+	}
+}
+
+func TestTournament_RepeatedByesScore(t *testing.T) {
+	tourn := NewTournament(0, "cup", Swiss)
+	for i := 0; i < 3; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i)})
+	}
+	tourn.CurrentRound = 4
+	tourn.Byes = map[Round]ParticipantID{1: 0, 2: 1, 3: 2, 4: 0}
+
+	tourn.recalculateScores()
+	if score := tourn.FindParticipantByPID(0).Score; score != 2*tourn.Scoring.Bye {
+		t.Fatalf("expected both byes to score, got %g", score)
+	}
+}
+
+func TestTournament_ResolveConflict(t *testing.T) {
+	tourn := NewTournament(0, "cup", Swiss)
+	for i := 0; i < 4; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	m := tourn.Matches[1][0]
+	if err := tourn.ReportScore(m.ID, m.P1, []GameScore{{2, 1}}); err != nil {
+		t.Fatalf("ReportScore() error = %v", err)
+	}
+	if err := tourn.ReportScore(m.ID, m.P2, []GameScore{{1, 3}}); err != nil {
+		t.Fatalf("ReportScore() error = %v", err)
+	}
+	if m.State != MatchConflicted {
+		t.Fatalf("expected a conflict, got %s", m.State)
+	}
+	if err := tourn.ResolveConflict(m.ID, "p3", "", 100); err != ErrUnknownResolution {
+		t.Fatalf("expected ErrUnknownResolution, got %v", err)
+	}
+
+	if err := tourn.ResolveConflict(m.ID, AcceptP2, "фото протокола", 100); err != nil {
+		t.Fatalf("ResolveConflict() error = %v", err)
+	}
+	if m.State != MatchCompleted || *m.Result != P2Won || FormatScore(m.Games) != "1:3" {
+		t.Fatalf("expected the claim of P2 to stand, got %s %v", m.State, m.Games)
+	}
+	if m.Resolution != AcceptP2 || m.ResolutionNote != "фото протокола" || m.ResolvedBy != 100 {
+		t.Fatalf("expected the resolution to be recorded on the match")
+	}
+	if err := tourn.ResolveConflict(m.ID, AcceptP1, "", 100); err != ErrNotConflicted {
+		t.Fatalf("expected ErrNotConflicted, got %v", err)
+	}
+}
+
+func TestTournament_FinalPlaces(t *testing.T) {
+	tourn := NewTournament(0, "knockout", SingleElimination)
+	for i := 0; i < 4; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	semis := tourn.Matches[1]
+	for !tourn.Finished() {
+		for _, m := range tourn.Matches[tourn.CurrentRound] {
+			if err := tourn.SetMatchResultByAdmin(m.ID, P1Won); err != nil {
+				t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+			}
+		}
+	}
+
+	places := tourn.FinalPlaces()
+	final := tourn.Matches[tourn.CurrentRound][0]
+	if places[final.P1] != 1 || places[final.P2] != 2 {
+		t.Fatalf("expected the finalists to take places 1 and 2, got %d and %d", places[final.P1], places[final.P2])
+	}
+	for _, m := range semis {
+		if places[m.P2] != 3 {
+			t.Fatalf("expected semifinal losers to share place 3, got %d", places[m.P2])
+		}
+	}
+}
+
+func TestTournament_ScheduleMatch(t *testing.T) {
+	tourn := NewTournament(0, "cup", Swiss)
+	for i := 0; i < 4; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	m := tourn.Matches[1][0]
+	if err := tourn.ProposeTime(m.ID, m.P1, now.Add(-time.Hour), now); err != ErrTimeInPast {
+		t.Fatalf("expected ErrTimeInPast, got %v", err)
+	}
+	if err := tourn.ProposeTime(m.ID, m.P1, now.Add(48*time.Hour), now); err != nil {
+		t.Fatalf("ProposeTime() error = %v", err)
+	}
+	if err := tourn.AcceptTime(m.ID, m.P1); err != ErrOwnProposal {
+		t.Fatalf("expected ErrOwnProposal, got %v", err)
+	}
+	// the opponent counter-proposes and the first side accepts
+	at := now.Add(30 * time.Hour)
+	if err := tourn.ProposeTime(m.ID, m.P2, at, now); err != nil {
+		t.Fatalf("ProposeTime() error = %v", err)
+	}
+	if err := tourn.AcceptTime(m.ID, m.P1); err != nil {
+		t.Fatalf("AcceptTime() error = %v", err)
+	}
+	if m.ScheduledAt == nil || !m.ScheduledAt.Equal(at) || m.ProposedAt != nil {
+		t.Fatalf("expected the match to be scheduled at the counter-proposal")
+	}
+
+	if due, _ := tourn.DueReminders(now); len(due) != 0 {
+		t.Fatalf("expected no reminders 30 hours before the match")
+	}
+	if due, _ := tourn.DueReminders(at.Add(-23 * time.Hour)); len(due) != 1 {
+		t.Fatalf("expected the 24 hour reminder")
+	}
+	if due, _ := tourn.DueReminders(at.Add(-22 * time.Hour)); len(due) != 0 {
+		t.Fatalf("expected each reminder to be sent once")
+	}
+	if due, _ := tourn.DueReminders(at.Add(time.Minute)); len(due) != 0 || m.Reminded != len(ReminderOffsets) {
+		t.Fatalf("expected no reminder after the match has started")
+	}
+}
+
+func TestTournament_Roles(t *testing.T) {
+	tourn := NewTournament(1, "cup", Swiss)
+	tourn.Staff = []*StaffMember{{UserID: 2, Role: RoleOrganizer}, {UserID: 3, Role: RoleJudge}}
+
+	if tourn.RoleOf(1) != RoleOwner || tourn.RoleOf(4) != "" {
+		t.Fatalf("expected the owner and a stranger to be told apart")
+	}
+	if !tourn.Can(3, PermResults) || tourn.Can(3, PermApplications) {
+		t.Fatalf("expected a judge to set results but not to review applications")
+	}
+	if !tourn.Can(2, PermApplications) || tourn.Can(2, PermStaff) {
+		t.Fatalf("expected a co-organizer to review applications but not to manage staff")
+	}
+	if ids := tourn.StaffWith(PermResults); len(ids) != 3 {
+		t.Fatalf("expected the owner and both staff members to get results, got %v", ids)
+	}
+	if ids := tourn.StaffWith(PermApplications); len(ids) != 2 {
+		t.Fatalf("expected the judge to be left out of applications, got %v", ids)
+	}
+}
+
+func TestTournament_Lifecycle(t *testing.T) {
+	tourn := NewTournament(0, "cup", SingleElimination)
+	if tourn.Status != StatusDraft {
+		t.Fatalf("expected a new tournament to be a draft, got %s", tourn.Status)
+	}
+	if err := tourn.SetStatus(StatusRegistrationClosed); err != ErrInvalidTransition {
+		t.Fatalf("expected registration to be opened first, got %v", err)
+	}
+	for _, s := range []Status{StatusRegistration, StatusRegistrationClosed} {
+		if err := tourn.SetStatus(s); err != nil {
+			t.Fatalf("SetStatus(%s) error = %v", s, err)
+		}
+	}
+	if err := tourn.SetStatus(StatusRunning); err != ErrInvalidTransition {
+		t.Fatalf("expected the start to go through Start(), got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Roster: []TelegramUserID{TelegramUserID(i)}})
+	}
+	if err := tourn.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if tourn.Status != StatusRunning {
+		t.Fatalf("expected a started tournament to be running, got %s", tourn.Status)
+	}
+	if err := tourn.SetMatchResultByAdmin(tourn.Matches[1][0].ID, P1Won); err != nil {
+		t.Fatalf("SetMatchResultByAdmin() error = %v", err)
+	}
+	if tourn.Status != StatusFinished {
+		t.Fatalf("expected the tournament to finish with its last match, got %s", tourn.Status)
+	}
+	if err := tourn.SetStatus(StatusCancelled); err != ErrInvalidTransition {
+		t.Fatalf("expected a finished tournament not to be cancelled, got %v", err)
+	}
+	if err := tourn.SetStatus(StatusArchived); err != nil {
+		t.Fatalf("SetStatus(archived) error = %v", err)
+	}
+}
+
+func TestTournament_Registration(t *testing.T) {
+	now := time.Now()
+	tourn := NewTournament(0, "cup", Swiss)
+	if tourn.RegistrationOpen(now) {
+		t.Fatalf("expected a draft not to take applications")
+	}
+	if err := tourn.SetStatus(StatusRegistration); err != nil {
+		t.Fatalf("SetStatus(registration) error = %v", err)
+	}
+	if err := tourn.SetRegistrationDeadline(&now, now); err != ErrTimeInPast {
+		t.Fatalf("expected a deadline in the past to be rejected, got %v", err)
+	}
+	deadline := now.Add(time.Hour)
+	if err := tourn.SetRegistrationDeadline(&deadline, now); err != nil {
+		t.Fatalf("SetRegistrationDeadline() error = %v", err)
+	}
+	if !tourn.RegistrationOpen(now) || tourn.RegistrationOpen(deadline) {
+		t.Fatalf("expected registration to be open only before the deadline")
+	}
+
+	for i := 0; i < 3; i++ {
+		tourn.Participants = append(tourn.Participants, &Participant{ID: ParticipantID(i), Name: string(rune('a' + i))})
+	}
+	if tourn.Full() {
+		t.Fatalf("expected a tournament without capacity never to be full")
+	}
+	if err := tourn.SetCapacity(2); err != ErrCapacityTooSmall {
+		t.Fatalf("expected capacity below the approved participants to be rejected, got %v", err)
+	}
+	if err := tourn.SetCapacity(3); err != nil {
+		t.Fatalf("SetCapacity() error = %v", err)
+	}
+	if !tourn.Full() {
+		t.Fatalf("expected the tournament to be full")
+	}
+
+	if err := tourn.Leave(1); err != nil {
+		t.Fatalf("Leave() error = %v", err)
+	}
+	if tourn.Full() || len(tourn.Participants) != 2 {
+		t.Fatalf("expected a seat to be freed, got %d participants", len(tourn.Participants))
+	}
+	if p := tourn.FindParticipantByPID(2); p == nil || p.Name != "c" || tourn.FindParticipantByPID(1) != nil {
+		t.Fatalf("expected the others to keep their IDs, got %+v", p)
+	}
+	if !reflect.DeepEqual(tourn.Removed, []ParticipantID{1}) || tourn.NextParticipantID() != 3 {
+		t.Fatalf("expected the participant to be listed for removal, got %v", tourn.Removed)
+	}
+
+	apps := []*Application{
+		{TelegramUserID: 1, Waitlisted: true, CreatedAt: now.Add(time.Minute)},
+		{TelegramUserID: 2},
+		{TelegramUserID: 3, Waitlisted: true, CreatedAt: now},
+	}
+	if list := Waitlist(apps); len(list) != 2 || list[0].TelegramUserID != 3 {
+		t.Fatalf("expected the waitlist in application order, got %v", list)
+	}
+}
+
+func TestTournament_ApplicationForm(t *testing.T) {
+	tourn := NewTournament(0, "cup", Swiss)
+	if err := tourn.AddFormField(&FormField{Question: "Club", Kind: FieldChoice, Options: []string{"A", " "}}); err != ErrInvalidField {
+		t.Fatalf("expected a choice with one option to be rejected, got %v", err)
+	}
+	fields := []*FormField{
+		{Question: "Nickname", Kind: FieldText, Required: true},
+		{Question: "Rating", Kind: FieldNumber},
+		{Question: "Club", Kind: FieldChoice, Options: []string{"Red", "Blue"}},
+		{Question: "I accept the rules", Kind: FieldYesNo, Required: true},
+	}
+	for _, f := range fields {
+		if err := tourn.AddFormField(f); err != nil {
+			t.Fatalf("AddFormField(%s) error = %v", f.Question, err)
+		}
+	}
+	if fields[3].ID != 4 {
+		t.Fatalf("expected fields to be numbered in order, got %d", fields[3].ID)
+	}
+
+	if _, err := tourn.CheckAnswers(map[int]string{4: "yes"}); err != ErrAnswerRequired {
+		t.Fatalf("expected the nickname to be required, got %v", err)
+	}
+	if _, err := tourn.CheckAnswers(map[int]string{1: "neo", 2: "high", 4: "yes"}); err != ErrInvalidAnswer {
+		t.Fatalf("expected a number to be checked, got %v", err)
+	}
+	if _, err := tourn.CheckAnswers(map[int]string{1: "neo", 4: "no"}); err != ErrMustAgree {
+		t.Fatalf("expected the rules to be accepted, got %v", err)
+	}
+	answers, err := tourn.CheckAnswers(map[int]string{1: " neo ", 2: "1500,50", 3: "2", 4: "Yes", 9: "x"})
+	if err != nil {
+		t.Fatalf("CheckAnswers() error = %v", err)
+	}
+	want := map[int]string{1: "neo", 2: "1500.5", 3: "Blue", 4: AnswerYes}
+	if !reflect.DeepEqual(answers, want) {
+		t.Fatalf("expected answers %v, got %v", want, answers)
+	}
+
+	if err := tourn.RemoveFormField(1); err != nil {
+		t.Fatalf("RemoveFormField() error = %v", err)
+	}
+	if _, err := tourn.CheckAnswers(map[int]string{4: "yes"}); err != nil {
+		t.Fatalf("expected the removed question not to be required, got %v", err)
+	}
+}
+
+func TestApplicationFilter(t *testing.T) {
+	now := time.Now()
+	apps := []*Application{
+		{TelegramUserID: 1, CreatedAt: now.Add(2 * time.Minute), Answers: map[int]string{1: "neo"}},
+		{TelegramUserID: 2, CreatedAt: now},
+		{TelegramUserID: 3, CreatedAt: now.Add(time.Minute), Answers: map[int]string{1: "trinity"}},
+	}
+	ids := func(list []*Application) []TelegramUserID {
+		var ids []TelegramUserID
+		for _, a := range list {
+			ids = append(ids, a.TelegramUserID)
+		}
+		return ids
+	}
+
+	if got := ids(ApplicationFilter{}.Apply(apps)); !reflect.DeepEqual(got, []TelegramUserID{2, 3, 1}) {
+		t.Fatalf("expected the oldest first, got %v", got)
+	}
+	if got := ids(ApplicationFilter{NewestFirst: true, AnsweredField: 1}.Apply(apps)); !reflect.DeepEqual(got, []TelegramUserID{1, 3}) {
+		t.Fatalf("expected the newest answered applications, got %v", got)
+	}
+}
+
+func TestTournament_Profile(t *testing.T) {
+	tourn := NewTournament(0, "cup", Swiss)
+
+	if err := tourn.SetProfileField(ProfileRules, "  Bo3, no draws  "); err != nil {
+		t.Fatalf("SetProfileField() error = %v", err)
+	}
+	if got := tourn.Profile.Get(ProfileRules); got != "Bo3, no draws" {
+		t.Fatalf("expected trimmed rules, got %q", got)
+	}
+	if err := tourn.SetProfileField("sponsor", "x"); err != ErrUnknownProfileField {
+		t.Fatalf("expected ErrUnknownProfileField, got %v", err)
+	}
+	if err := tourn.SetProfileField(ProfilePrizes, strings.Repeat("я", MaxProfileLength+1)); err != ErrProfileTooLong {
+		t.Fatalf("expected ErrProfileTooLong, got %v", err)
+	}
+	if err := tourn.SetProfileField(ProfileRules, ""); err != nil || tourn.Profile.Rules != "" {
+		t.Fatalf("expected the rules to be cleared, got %q, %v", tourn.Profile.Rules, err)
+	}
+
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	if err := tourn.SetStartDate(&past, now); err != ErrTimeInPast {
+		t.Fatalf("expected ErrTimeInPast, got %v", err)
+	}
+	next := now.Add(24 * time.Hour)
+	if err := tourn.SetStartDate(&next, now); err != nil || tourn.Profile.StartsAt != &next {
+		t.Fatalf("expected the start date to be set, got %v", err)
+	}
+}
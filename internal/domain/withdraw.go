@@ -0,0 +1,65 @@
+package domain
+
+import "errors"
+
+var (
+	ErrNotParticipant       = errors.New("user is not in tournament")
+	ErrAlreadyWithdrawn     = errors.New("participant has already withdrawn")
+	ErrCaptainWithdrawsTeam = errors.New("only the team captain can withdraw the team")
+)
+
+// Withdraw takes the participant out of a running tournament: their
+// pending match is lost by forfeit and they are not drawn any more, but
+// they stay in the standings.
+func (t *Tournament) Withdraw(pID ParticipantID) error {
+	p := t.FindParticipantByPID(pID)
+	if p == nil {
+		return ErrUnknownParticipant
+	}
+	if p.Withdrawn {
+		return ErrAlreadyWithdrawn
+	}
+
+	p.Withdrawn = true
+	p.Eliminated = true
+	if !t.forfeitWithdrawn() {
+		return nil
+	}
+	if err := t.DrawNewRound(); errors.Is(err, ErrNoLegalPairing) {
+		return err
+	}
+	return nil
+}
+
+// forfeitWithdrawn awards the unfinished matches of the current round
+// that involve a withdrawn participant to the opponent, both lose if both
+// withdrew, and reports whether there were any.
+func (t *Tournament) forfeitWithdrawn() bool {
+	var changed bool
+	for _, m := range t.Matches[t.CurrentRound] {
+		if m.State == MatchCompleted {
+			continue
+		}
+		var result ResultType
+		switch {
+		case t.FindParticipantByPID(m.P1).Withdrawn:
+			result = P2Won
+			if t.FindParticipantByPID(m.P2).Withdrawn {
+				result = DoubleForfeit
+				if t.System.Elimination() {
+					result = P1Won // somebody has to advance in a bracket
+				}
+			}
+		case t.FindParticipantByPID(m.P2).Withdrawn:
+			result = P1Won
+		default:
+			continue
+		}
+		m.Result = &result
+		m.Games = nil
+		m.Forfeit = true
+		m.State = MatchCompleted
+		changed = true
+	}
+	return changed
+}
@@ -2,23 +2,48 @@ package service
 
 import (
 	"errors"
-	"math"
+	"strings"
 	"time"
 
 	"github.com/Ycyken/tournament-bot/internal/domain"
 	"github.com/Ycyken/tournament-bot/internal/store"
 )
 
+// Notifier is told about progress of tournaments, e.g. to message
+// participants.
+type Notifier interface {
+	RoundDrawn(t *domain.Tournament)
+	TournamentFinished(t *domain.Tournament)
+	WaitlistPromoted(t *domain.Tournament, app *domain.Application)
+}
+
 type Service struct {
-	store store.Store
+	store    store.Store
+	notifier Notifier
 }
 
 func New(s store.Store) *Service {
 	return &Service{store: s}
 }
 
-func (s *Service) CreateTournament(owner domain.TelegramUserID, title string) (*domain.Tournament, error) {
-	t := domain.NewTournament(owner, title, domain.Swiss)
+func (s *Service) SetNotifier(n Notifier) {
+	s.notifier = n
+}
+
+// announce reports a new round or the end of t to the notifier, given the
+// round t was in and whether it was finished before the last change.
+func (s *Service) announce(t *domain.Tournament, round domain.Round, finished bool) {
+	if s.notifier == nil {
+		return
+	}
+	if !finished && t.Finished() {
+		s.notifier.TournamentFinished(t)
+	} else if t.CurrentRound > round {
+		s.notifier.RoundDrawn(t)
+	}
+}
+
+func (s *Service) CreateTournament(t *domain.Tournament) (*domain.Tournament, error) {
 	id, err := s.store.CreateTournament(t)
 	if err != nil {
 		return nil, err
@@ -29,29 +54,32 @@ func (s *Service) CreateTournament(owner domain.TelegramUserID, title string) (*
 	return t, nil
 }
 
-func (s *Service) StartTournament(tid domain.TournamentID) error {
+func (s *Service) StartTournament(tid domain.TournamentID, adminID domain.TelegramUserID) error {
 	t, err := s.store.GetTournament(tid)
 	if err != nil {
 		return err
 	}
 
-	if t.CurrentRound > 0 {
-		return errors.New("tournament already started")
+	if !t.Can(adminID, domain.PermSettings) {
+		return domain.ErrForbidden
 	}
 
-	participantsCount := len(t.Participants)
-	if participantsCount < 2 {
-		return errors.New("need at least 2 participants to start tournament")
+	// starting a draft or an open registration closes it on the way
+	if t.Status == domain.StatusDraft {
+		if err := t.SetStatus(domain.StatusRegistration); err != nil {
+			return err
+		}
 	}
-
-	// ⌊log₂(N)⌋ + 1
-	if participantsCount == 2 {
-		t.LastRound = 1
-	} else {
-		t.LastRound = domain.Round(int(math.Log2(float64(len(t.Participants)))) + 1)
+	if t.Status == domain.StatusRegistration {
+		if err := t.SetStatus(domain.StatusRegistrationClosed); err != nil {
+			return err
+		}
+	}
+	if !t.Status.CanMoveTo(domain.StatusRunning) {
+		return domain.ErrInvalidTransition
 	}
 
-	if err := t.DrawNewRound(); err != nil {
+	if err := t.Start(); err != nil {
 		return err
 	}
 
@@ -59,122 +87,296 @@ func (s *Service) StartTournament(tid domain.TournamentID) error {
 	if err != nil {
 		return err
 	}
+	s.announce(t, 0, false)
 
 	return nil
 }
 
-func (s *Service) GetUserTournaments(userID domain.TelegramUserID) (map[domain.TournamentID]string, error) {
-	return s.store.GetUserTournaments(userID)
+// SetStatus moves the tournament along its lifecycle: opens and closes
+// registration, finishes, cancels or archives it.
+func (s *Service) SetStatus(tid domain.TournamentID, adminID domain.TelegramUserID, status domain.Status) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermSettings) {
+		return nil, domain.ErrForbidden
+	}
+
+	if err := t.SetStatus(status); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+	if status == domain.StatusFinished && s.notifier != nil {
+		s.notifier.TournamentFinished(t)
+	}
+
+	return t, nil
 }
 
-func (s *Service) ApplyToTournament(app *domain.Application) error {
-	t, err := s.store.GetTournament(app.TournamentID)
+func (s *Service) SetTieBreaks(tid domain.TournamentID, adminID domain.TelegramUserID, tieBreaks []domain.TieBreak) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if t.CurrentRound > 0 {
-		return errors.New("tournament already started")
+	if !t.Can(adminID, domain.PermSettings) {
+		return nil, domain.ErrForbidden
+	}
+	if t.Status.Over() {
+		return nil, domain.ErrTournamentClosed
 	}
 
-	for _, p := range t.Participants {
-		for _, id := range p.Roster {
-			if id == app.TelegramUserID {
-				return errors.New("user already in tournament")
-			}
-		}
+	t.TieBreaks = tieBreaks
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
 	}
 
-	appls, err := s.GetApplications(app.TournamentID)
+	return t, nil
+}
+
+func (s *Service) SetScoringRules(tid domain.TournamentID, adminID domain.TelegramUserID, rules domain.ScoringRules) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	for _, a := range appls {
-		if a.TelegramUserID == app.TelegramUserID {
-			return errors.New("user is already applied to this tournament")
-		}
+
+	if !t.Can(adminID, domain.PermSettings) {
+		return nil, domain.ErrForbidden
+	}
+	if t.Status.Over() {
+		return nil, domain.ErrTournamentClosed
+	}
+
+	if err := t.SetScoringRules(rules); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
 	}
 
-	return s.store.CreateApplication(app)
+	return t, nil
 }
 
-func (s *Service) ApproveApplication(tID domain.TournamentID, tgID domain.TelegramUserID) error {
-	t, err := s.store.GetTournament(tID)
+func (s *Service) SetBestOf(tid domain.TournamentID, adminID domain.TelegramUserID, round domain.Round, bestOf int) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermSettings) {
+		return nil, domain.ErrForbidden
+	}
+	if t.Status.Over() {
+		return nil, domain.ErrTournamentClosed
+	}
+
+	if err := t.SetBestOf(round, bestOf); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Service) SetTeamMode(tid domain.TournamentID, adminID domain.TelegramUserID, teamMode, rosterReports bool) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
+	if err != nil {
+		return nil, err
 	}
 
+	if !t.Can(adminID, domain.PermSettings) {
+		return nil, domain.ErrForbidden
+	}
+	if t.Status.Over() {
+		return nil, domain.ErrTournamentClosed
+	}
 	if t.CurrentRound > 0 {
-		return errors.New("tournament already started")
+		return nil, domain.ErrAlreadyStarted
 	}
 
-	app, err := s.store.GetApplication(tID, tgID)
+	t.TeamMode = teamMode
+	t.RosterReports = rosterReports
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Service) SetDeadline(tid domain.TournamentID, adminID domain.TelegramUserID, round domain.Round, at time.Time) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	p := &domain.Participant{
-		ID:           domain.ParticipantID(len(t.Participants)),
-		Name:         app.Name,
-		TelegramTag:  app.TelegramTag,
-		TournamentID: app.TournamentID,
-		Kind:         domain.ParticipantKindUser,
-		Roster:       []domain.TelegramUserID{app.TelegramUserID},
-		JoinedAt:     time.Now(),
+
+	if !t.Can(adminID, domain.PermSettings) {
+		return nil, domain.ErrForbidden
+	}
+	if t.Status.Over() {
+		return nil, domain.ErrTournamentClosed
 	}
 
-	t.Participants = append(t.Participants, p)
+	if err := t.SetDeadline(round, at); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
 
-	if err := s.store.DeleteApplication(tID, tgID); err != nil {
-		return err
+	return t, nil
+}
+
+func (s *Service) SetDeadlinePolicy(tid domain.TournamentID, adminID domain.TelegramUserID, policy domain.DeadlinePolicy) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
+	if err != nil {
+		return nil, err
 	}
+
+	if !t.Can(adminID, domain.PermSettings) {
+		return nil, domain.ErrForbidden
+	}
+	if t.Status.Over() {
+		return nil, domain.ErrTournamentClosed
+	}
+	if policy != domain.DeadlineForfeit && policy != domain.DeadlineEscalate {
+		return nil, domain.ErrUnknownDeadlinePolicy
+	}
+
+	t.DeadlinePolicy = policy
 	if err := s.store.SaveTournament(t); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return t, nil
+}
+
+func (s *Service) SetProfileField(tid domain.TournamentID, adminID domain.TelegramUserID, field domain.ProfileField, value string) (*domain.Tournament, error) {
+	t, err := s.getProfileSettings(tid, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.SetProfileField(field, value); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
 }
 
-func (s *Service) RejectApplication(tID domain.TournamentID, tgID domain.TelegramUserID) error {
-	return s.store.DeleteApplication(tID, tgID)
+func (s *Service) SetStartDate(tid domain.TournamentID, adminID domain.TelegramUserID, at *time.Time) (*domain.Tournament, error) {
+	t, err := s.getProfileSettings(tid, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.SetStartDate(at, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
 }
 
-func (s *Service) GetApplications(tid domain.TournamentID) ([]*domain.Application, error) {
-	return s.store.GetApplications(tid)
+func (s *Service) getProfileSettings(tid domain.TournamentID, adminID domain.TelegramUserID) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermSettings) {
+		return nil, domain.ErrForbidden
+	}
+	if t.Status.Over() {
+		return nil, domain.ErrTournamentClosed
+	}
+
+	return t, nil
 }
 
-func (s *Service) GetApplication(tid domain.TournamentID, uid domain.TelegramUserID) (*domain.Application, error) {
-	return s.store.GetApplication(tid, uid)
+func (s *Service) SetCapacity(tid domain.TournamentID, adminID domain.TelegramUserID, capacity int) (*domain.Tournament, error) {
+	t, err := s.getRegistrationSettings(tid, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.SetCapacity(capacity); err != nil {
+		return nil, err
+	}
+	// a raised limit lets the waitlist in
+	if err := s.promoteWaitlist(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
 }
 
-func (s *Service) GetTournament(tid domain.TournamentID) (*domain.Tournament, error) {
-	return s.store.GetTournament(tid)
+func (s *Service) SetRegistrationDeadline(tid domain.TournamentID, adminID domain.TelegramUserID, at *time.Time) (*domain.Tournament, error) {
+	t, err := s.getRegistrationSettings(tid, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.SetRegistrationDeadline(at, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
 }
 
-func (s *Service) GetTournaments() ([]*domain.Tournament, error) {
-	return s.store.GetTournaments()
+func (s *Service) SetAutoApprove(tid domain.TournamentID, adminID domain.TelegramUserID, autoApprove bool) (*domain.Tournament, error) {
+	t, err := s.getRegistrationSettings(tid, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	t.AutoApprove = autoApprove
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
 }
 
-func (s *Service) ReportMatchResult(tournamentID domain.TournamentID, matchID domain.MatchID, userID domain.TelegramUserID, result domain.ResultType) (*domain.Tournament, error) {
-	t, err := s.store.GetTournament(tournamentID)
+func (s *Service) AddFormField(tid domain.TournamentID, adminID domain.TelegramUserID, f *domain.FormField) (*domain.Tournament, error) {
+	t, err := s.getRegistrationSettings(tid, adminID)
 	if err != nil {
 		return nil, err
 	}
 
-	var participantID domain.ParticipantID = -1
-outerLoop:
-	for _, p := range t.Participants {
-		for _, id := range p.Roster {
-			if id == userID {
-				participantID = p.ID
-				break outerLoop
-			}
-		}
+	if err := t.AddFormField(f); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
 	}
 
-	if err := t.ReportOpinion(matchID, participantID, result); err != nil {
+	return t, nil
+}
+
+func (s *Service) RemoveFormField(tid domain.TournamentID, adminID domain.TelegramUserID, id int) (*domain.Tournament, error) {
+	t, err := s.getRegistrationSettings(tid, adminID)
+	if err != nil {
 		return nil, err
 	}
 
+	if err := t.RemoveFormField(id); err != nil {
+		return nil, err
+	}
 	if err := s.store.SaveTournament(t); err != nil {
 		return nil, err
 	}
@@ -182,17 +384,116 @@ outerLoop:
 	return t, nil
 }
 
-func (s *Service) SetMatchResultByAdmin(tournamentID domain.TournamentID, matchID domain.MatchID, adminID domain.TelegramUserID, result domain.ResultType) (*domain.Tournament, error) {
-	t, err := s.store.GetTournament(tournamentID)
+func (s *Service) SetFormFieldRequired(tid domain.TournamentID, adminID domain.TelegramUserID, id int, required bool) (*domain.Tournament, error) {
+	t, err := s.getRegistrationSettings(tid, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	f := t.FindFormField(id)
+	if f == nil {
+		return nil, domain.ErrUnknownField
+	}
+	f.Required = required
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Service) getRegistrationSettings(tid domain.TournamentID, adminID domain.TelegramUserID) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermSettings) {
+		return nil, domain.ErrForbidden
+	}
+	if !t.Status.Preparing() {
+		return nil, domain.ErrAlreadyStarted
+	}
+
+	return t, nil
+}
+
+// CloseRegistrations closes registration of the tournaments whose
+// registration deadline has passed and returns them.
+func (s *Service) CloseRegistrations(now time.Time) ([]*domain.Tournament, error) {
+	ids, err := s.store.GetExpiredRegistrations(now)
+	if err != nil {
+		return nil, err
+	}
+
+	var closed []*domain.Tournament
+	var errs []error
+	for _, id := range ids {
+		t, err := s.store.GetTournament(id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := t.SetStatus(domain.StatusRegistrationClosed); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := s.store.SaveTournament(t); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		closed = append(closed, t)
+	}
+
+	return closed, errors.Join(errs...)
+}
+
+type DeadlineReport struct {
+	Tournament *domain.Tournament
+	Outcome    *domain.DeadlineOutcome
+	DrawErr    error // the round after the forfeits could not be paired
+}
+
+// ExpireDeadlines applies the deadline policy to every round whose deadline
+// has passed. A failing tournament does not stop the others.
+func (s *Service) ExpireDeadlines(now time.Time) ([]*DeadlineReport, error) {
+	ids, err := s.store.GetDueDeadlines(now)
 	if err != nil {
 		return nil, err
 	}
 
-	if t.OwnerID != adminID {
-		return nil, errors.New("only tournament owner can set match results")
+	var reports []*DeadlineReport
+	var errs []error
+	for _, id := range ids {
+		t, err := s.store.GetTournament(id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		round, finished := t.CurrentRound, t.Finished()
+		outcome, drawErr := t.ExpireDeadline(now)
+		if outcome == nil {
+			continue
+		}
+		if err := s.saveRated(t); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		s.announce(t, round, finished)
+		reports = append(reports, &DeadlineReport{Tournament: t, Outcome: outcome, DrawErr: drawErr})
+	}
+
+	return reports, errors.Join(errs...)
+}
+
+func (s *Service) ProposeMatchTime(tid domain.TournamentID, mid domain.MatchID, userID domain.TelegramUserID, at time.Time) (*domain.Tournament, error) {
+	t, p, err := s.getReporter(tid, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := t.SetMatchResultByAdmin(matchID, result); err != nil {
+	if err := t.ProposeTime(mid, p.ID, at.UTC(), time.Now()); err != nil {
 		return nil, err
 	}
 
@@ -202,3 +503,907 @@ func (s *Service) SetMatchResultByAdmin(tournamentID domain.TournamentID, matchI
 
 	return t, nil
 }
+
+func (s *Service) AcceptMatchTime(tid domain.TournamentID, mid domain.MatchID, userID domain.TelegramUserID) (*domain.Tournament, error) {
+	t, p, err := s.getReporter(tid, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.AcceptTime(mid, p.ID); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// getReporter loads the tournament and the participant the user may act
+// for: themselves, or their team if they are allowed to report for it.
+func (s *Service) getReporter(tid domain.TournamentID, userID domain.TelegramUserID) (*domain.Tournament, *domain.Participant, error) {
+	t, err := s.store.GetTournament(tid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if t.Status != domain.StatusRunning {
+		return nil, nil, domain.ErrNotRunning
+	}
+
+	p := t.FindParticipantBytgID(userID)
+	if p == nil {
+		return nil, nil, domain.ErrParticipantNotInMatch
+	}
+	if !t.CanReport(p, userID) {
+		return nil, nil, domain.ErrNotCaptain
+	}
+
+	return t, p, nil
+}
+
+type ReminderReport struct {
+	Tournament *domain.Tournament
+	Matches    []*domain.Match
+}
+
+// DueReminders collects the scheduled matches players should be reminded
+// of. A failing tournament does not stop the others.
+func (s *Service) DueReminders(now time.Time) ([]*ReminderReport, error) {
+	ids, err := s.store.GetDueReminders(now.Add(domain.ReminderOffsets[0]), len(domain.ReminderOffsets))
+	if err != nil {
+		return nil, err
+	}
+
+	var reports []*ReminderReport
+	var errs []error
+	for _, id := range ids {
+		t, err := s.store.GetTournament(id)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		matches, marked := t.DueReminders(now)
+		if len(marked) == 0 {
+			continue
+		}
+		if err := s.store.SaveReminders(t.ID, marked); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(matches) > 0 {
+			reports = append(reports, &ReminderReport{Tournament: t, Matches: matches})
+		}
+	}
+
+	return reports, errors.Join(errs...)
+}
+
+// GetUserLocation returns the time zone the user has chosen, the server
+// one by default.
+func (s *Service) GetUserLocation(userID domain.TelegramUserID) (*time.Location, error) {
+	tz, err := s.store.GetUserTimezone(userID)
+	if err != nil || tz == "" {
+		return time.Local, err
+	}
+	return time.LoadLocation(tz)
+}
+
+func (s *Service) SetUserTimezone(userID domain.TelegramUserID, tz string) error {
+	if _, err := time.LoadLocation(tz); err != nil {
+		return err
+	}
+	return s.store.SetUserTimezone(userID, tz)
+}
+
+func (s *Service) getSeedableTournament(tid domain.TournamentID, adminID domain.TelegramUserID) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermSettings) {
+		return nil, domain.ErrForbidden
+	}
+	if t.Status.Over() {
+		return nil, domain.ErrTournamentClosed
+	}
+	if t.CurrentRound > 0 {
+		return nil, domain.ErrAlreadyStarted
+	}
+
+	return t, nil
+}
+
+func (s *Service) SetSeeding(tid domain.TournamentID, adminID domain.TelegramUserID, method domain.SeedingMethod) (*domain.Tournament, error) {
+	t, err := s.getSeedableTournament(tid, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Seeding = method
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Service) SetSeeds(tid domain.TournamentID, adminID domain.TelegramUserID, order []domain.ParticipantID) (*domain.Tournament, error) {
+	t, err := s.getSeedableTournament(tid, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.SetSeeds(order); err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Service) SetParticipantRatings(tid domain.TournamentID, adminID domain.TelegramUserID, ratings map[domain.ParticipantID]int) (*domain.Tournament, error) {
+	t, err := s.getSeedableTournament(tid, adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	for id, rating := range ratings {
+		p := t.FindParticipantByPID(id)
+		if p == nil {
+			return nil, domain.ErrUnknownParticipant
+		}
+		p.Rating = rating
+	}
+	if err := s.store.SaveTournament(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// CreateRoleInvite makes a one-time code that gives its user role in the
+// tournament.
+func (s *Service) CreateRoleInvite(tid domain.TournamentID, adminID domain.TelegramUserID, role domain.Role) (string, error) {
+	if !domain.ValidStaffRole(role) {
+		return "", domain.ErrUnknownRole
+	}
+	if err := s.authorize(tid, adminID, domain.PermStaff); err != nil {
+		return "", err
+	}
+
+	code, err := domain.NewInviteCode()
+	if err != nil {
+		return "", err
+	}
+	if err := s.store.CreateRoleInvite(code, tid, role); err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+func (s *Service) AcceptRoleInvite(code string, uid domain.TelegramUserID, name string) (*domain.Tournament, *domain.StaffMember, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	tid, role, err := s.store.GetRoleInvite(code)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tid == 0 {
+		return nil, nil, domain.ErrInviteNotFound
+	}
+
+	t, err := s.store.GetTournament(tid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if t.RoleOf(uid) == domain.RoleOwner {
+		return nil, nil, domain.ErrOwnerInvite
+	}
+	if t.UserParticipates(uid) {
+		return nil, nil, domain.ErrAlreadyParticipant
+	}
+
+	m := &domain.StaffMember{UserID: uid, Role: role, Name: name}
+	used, err := s.store.UseRoleInvite(code, tid, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !used {
+		return nil, nil, domain.ErrInviteNotFound
+	}
+	t.Staff = append(t.Staff, m)
+	return t, m, nil
+}
+
+func (s *Service) RemoveStaffMember(tid domain.TournamentID, adminID, uid domain.TelegramUserID) error {
+	if err := s.authorize(tid, adminID, domain.PermStaff); err != nil {
+		return err
+	}
+	return s.store.DeleteStaffMember(tid, uid)
+}
+
+func (s *Service) GetUserTournaments(userID domain.TelegramUserID) (map[domain.TournamentID]string, error) {
+	return s.store.GetUserTournaments(userID)
+}
+
+// ApplyToTournament files the application and reports whether it was
+// approved right away. A full tournament puts the application on the
+// waitlist instead.
+func (s *Service) ApplyToTournament(app *domain.Application) (bool, error) {
+	t, err := s.store.GetTournament(app.TournamentID)
+	if err != nil {
+		return false, err
+	}
+
+	if !t.RegistrationOpen(time.Now()) {
+		return false, domain.ErrRegistrationShut
+	}
+
+	if err := s.checkNotEntered(t, app.TelegramUserID); err != nil {
+		return false, err
+	}
+	answers, err := t.CheckAnswers(app.Answers)
+	if err != nil {
+		return false, err
+	}
+	app.Answers = answers
+
+	if t.TeamMode {
+		if app.InviteCode, err = domain.NewInviteCode(); err != nil {
+			return false, err
+		}
+	}
+	app.Waitlisted = t.Full()
+	app.CreatedAt = time.Now()
+	if err := s.store.CreateApplication(app); err != nil {
+		return false, err
+	}
+
+	// a team is approved by hand once its roster is gathered
+	if !t.AutoApprove || t.TeamMode || app.Waitlisted {
+		return false, nil
+	}
+	if err := s.admit(t, app); err != nil {
+		return false, err
+	}
+	return true, s.store.ApproveApplications(t, []*domain.Application{app})
+}
+
+// JoinTeam adds the user to the roster of the team application with the
+// given invite code.
+func (s *Service) JoinTeam(code string, uid domain.TelegramUserID) (*domain.Application, error) {
+	app, err := s.store.GetApplicationByInviteCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, domain.ErrInviteNotFound
+	}
+
+	t, err := s.store.GetTournament(app.TournamentID)
+	if err != nil {
+		return nil, err
+	}
+	if !t.RegistrationOpen(time.Now()) {
+		return nil, domain.ErrRegistrationShut
+	}
+	if err := s.checkNotEntered(t, uid); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.AddApplicationMember(app.TournamentID, app.TelegramUserID, uid); err != nil {
+		return nil, err
+	}
+	app.Members = append(app.Members, uid)
+	return app, nil
+}
+
+// checkNotEntered makes sure the user neither plays in the tournament nor
+// is listed in one of its applications.
+func (s *Service) checkNotEntered(t *domain.Tournament, uid domain.TelegramUserID) error {
+	if t.UserParticipates(uid) {
+		return domain.ErrAlreadyParticipant
+	}
+
+	appls, err := s.store.GetApplications(t.ID)
+	if err != nil {
+		return err
+	}
+	for _, a := range appls {
+		if a.Includes(uid) {
+			return domain.ErrAlreadyApplied
+		}
+	}
+	return nil
+}
+
+func (s *Service) ApproveApplication(tID domain.TournamentID, adminID, tgID domain.TelegramUserID) error {
+	t, err := s.store.GetTournament(tID)
+	if err != nil {
+		return err
+	}
+
+	if !t.Can(adminID, domain.PermApplications) {
+		return domain.ErrForbidden
+	}
+
+	if !t.Status.Preparing() {
+		return domain.ErrAlreadyStarted
+	}
+	if t.Full() {
+		return domain.ErrTournamentFull
+	}
+
+	app, err := s.store.GetApplication(tID, tgID)
+	if err != nil {
+		return err
+	}
+	if app == nil {
+		return domain.ErrApplicationNotFound
+	}
+	if err := s.admit(t, app); err != nil {
+		return err
+	}
+	return s.store.ApproveApplications(t, []*domain.Application{app})
+}
+
+// ApproveApplications approves the applications of the users in the given
+// order while there is room, all in one transaction, and returns the
+// approved ones.
+func (s *Service) ApproveApplications(tID domain.TournamentID, adminID domain.TelegramUserID, userIDs []domain.TelegramUserID) ([]*domain.Application, error) {
+	t, err := s.store.GetTournament(tID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermApplications) {
+		return nil, domain.ErrForbidden
+	}
+	if !t.Status.Preparing() {
+		return nil, domain.ErrAlreadyStarted
+	}
+
+	apps, err := s.getApplicationsOf(tID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	var approved []*domain.Application
+	for _, app := range apps {
+		if t.Full() {
+			break
+		}
+		if err := s.admit(t, app); err != nil {
+			return nil, err
+		}
+		approved = append(approved, app)
+	}
+	if len(approved) == 0 && len(apps) > 0 {
+		return nil, domain.ErrTournamentFull
+	}
+
+	if err := s.store.ApproveApplications(t, approved); err != nil {
+		return nil, err
+	}
+	return approved, nil
+}
+
+// RejectApplications rejects the applications of the users in one
+// transaction and returns them.
+func (s *Service) RejectApplications(tID domain.TournamentID, adminID domain.TelegramUserID, userIDs []domain.TelegramUserID) ([]*domain.Application, error) {
+	if err := s.authorize(tID, adminID, domain.PermApplications); err != nil {
+		return nil, err
+	}
+
+	apps, err := s.getApplicationsOf(tID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.RejectApplications(tID, userIDs, ""); err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+// getApplicationsOf returns the applications of the users in their order.
+func (s *Service) getApplicationsOf(tID domain.TournamentID, userIDs []domain.TelegramUserID) ([]*domain.Application, error) {
+	all, err := s.store.GetApplications(tID)
+	if err != nil {
+		return nil, err
+	}
+	byUser := make(map[domain.TelegramUserID]*domain.Application)
+	for _, app := range all {
+		byUser[app.TelegramUserID] = app
+	}
+
+	var apps []*domain.Application
+	for _, uid := range userIDs {
+		app := byUser[uid]
+		if app == nil {
+			return nil, domain.ErrApplicationNotFound
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// admit turns the application into a participant of t. The caller saves t
+// and deletes the application.
+func (s *Service) admit(t *domain.Tournament, app *domain.Application) error {
+	roster := app.Roster()
+	ratings, err := s.store.GetPlayerRatings(t.Game, roster)
+	if err != nil {
+		return err
+	}
+	// a team is seeded by the average rating of its roster
+	var rating int
+	for _, uid := range roster {
+		if r := ratings[uid]; r != nil {
+			rating += r.Rating
+		} else {
+			rating += domain.DefaultRating
+		}
+	}
+	rating /= len(roster)
+
+	kind := domain.ParticipantKindUser
+	if t.TeamMode {
+		kind = domain.ParticipantKindTeam
+	}
+	p := &domain.Participant{
+		ID:           t.NextParticipantID(),
+		Name:         app.Name,
+		TelegramTag:  app.TelegramTag,
+		TournamentID: app.TournamentID,
+		Kind:         kind,
+		Roster:       roster,
+		Captain:      app.TelegramUserID,
+		Rating:       rating,
+		JoinedAt:     time.Now(),
+	}
+
+	t.Participants = append(t.Participants, p)
+	return nil
+}
+
+// promoteWaitlist fills the free seats of t from the waitlist, saves t and
+// tells the promoted applicants.
+func (s *Service) promoteWaitlist(t *domain.Tournament) error {
+	apps, err := s.store.GetApplications(t.ID)
+	if err != nil {
+		return err
+	}
+
+	var promoted []*domain.Application
+	for _, app := range domain.Waitlist(apps) {
+		if t.Full() {
+			break
+		}
+		if err := s.admit(t, app); err != nil {
+			return err
+		}
+		promoted = append(promoted, app)
+	}
+
+	if err := s.store.ApproveApplications(t, promoted); err != nil {
+		return err
+	}
+	if s.notifier != nil {
+		for _, app := range promoted {
+			s.notifier.WaitlistPromoted(t, app)
+		}
+	}
+	return nil
+}
+
+// RejectApplication rejects the application, the reason is shown to the
+// applicant and may be empty.
+func (s *Service) RejectApplication(tID domain.TournamentID, adminID, tgID domain.TelegramUserID, reason string) error {
+	if err := s.authorize(tID, adminID, domain.PermApplications); err != nil {
+		return err
+	}
+	return s.store.RejectApplications(tID, []domain.TelegramUserID{tgID}, reason)
+}
+
+func (s *Service) GetUserApplications(uid domain.TelegramUserID) ([]*domain.ApplicationRecord, error) {
+	return s.store.GetUserApplications(uid)
+}
+
+// EditApplication changes the name and the text of a pending application
+// of the user, an empty text removes it.
+func (s *Service) EditApplication(tID domain.TournamentID, uid domain.TelegramUserID, name, text string) (*domain.Application, error) {
+	app, err := s.getOwnApplication(tID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, domain.ErrEmptyName
+	}
+	app.Name = name
+	app.Text = nil
+	if text = strings.TrimSpace(text); text != "" {
+		app.Text = &text
+	}
+
+	if err := s.store.UpdateApplication(app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+// WithdrawApplication deletes a pending application of the user.
+func (s *Service) WithdrawApplication(tID domain.TournamentID, uid domain.TelegramUserID) (*domain.Application, error) {
+	app, err := s.getOwnApplication(tID, uid)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.DeleteApplication(tID, uid); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+func (s *Service) getOwnApplication(tID domain.TournamentID, uid domain.TelegramUserID) (*domain.Application, error) {
+	app, err := s.store.GetApplication(tID, uid)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, domain.ErrApplicationNotFound
+	}
+	return app, nil
+}
+
+func (s *Service) GetApplications(tid domain.TournamentID, adminID domain.TelegramUserID) ([]*domain.Application, error) {
+	if err := s.authorize(tid, adminID, domain.PermApplications); err != nil {
+		return nil, err
+	}
+	return s.store.GetApplications(tid)
+}
+
+// authorize checks perm of the user in a tournament that is not loaded yet.
+func (s *Service) authorize(tid domain.TournamentID, uid domain.TelegramUserID, perm domain.Permission) error {
+	_, err := s.GetManagedTournament(tid, uid, perm)
+	return err
+}
+
+func (s *Service) GetApplication(tid domain.TournamentID, uid domain.TelegramUserID) (*domain.Application, error) {
+	return s.store.GetApplication(tid, uid)
+}
+
+func (s *Service) GetUserRatings(uid domain.TelegramUserID) ([]*domain.PlayerRating, error) {
+	return s.store.GetUserRatings(uid)
+}
+
+func (s *Service) GetRatingHistory(uid domain.TelegramUserID, limit int) ([]*domain.RatingChange, error) {
+	return s.store.GetRatingHistory(uid, limit)
+}
+
+func (s *Service) GetTournament(tid domain.TournamentID) (*domain.Tournament, error) {
+	return s.store.GetTournament(tid)
+}
+
+// GetManagedTournament returns the tournament if the user is allowed perm
+// in it, for the organizer views.
+func (s *Service) GetManagedTournament(tid domain.TournamentID, uid domain.TelegramUserID, perm domain.Permission) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tid)
+	if err != nil {
+		return nil, err
+	}
+	if !t.Can(uid, perm) {
+		return nil, domain.ErrForbidden
+	}
+	return t, nil
+}
+
+func (s *Service) GetTournaments() ([]*domain.Tournament, error) {
+	return s.store.GetTournaments()
+}
+
+func (s *Service) ReportMatchResult(tournamentID domain.TournamentID, matchID domain.MatchID, userID domain.TelegramUserID, result domain.ResultType) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var participantID domain.ParticipantID = -1
+	if p := t.FindParticipantBytgID(userID); p != nil {
+		if !t.CanReport(p, userID) {
+			return nil, domain.ErrNotCaptain
+		}
+		participantID = p.ID
+	}
+
+	if t.Status != domain.StatusRunning {
+		return nil, domain.ErrNotRunning
+	}
+	round, finished := t.CurrentRound, t.Finished()
+	drawErr := t.ReportOpinion(matchID, participantID, result)
+	// the result stays recorded even if the next round cannot be paired
+	if drawErr != nil && !errors.Is(drawErr, domain.ErrNoLegalPairing) {
+		return nil, drawErr
+	}
+
+	if err := s.saveRated(t); err != nil {
+		return nil, err
+	}
+	s.announce(t, round, finished)
+
+	return t, drawErr
+}
+
+// ReportMatchScore reports the score from the side of the reporting player.
+func (s *Service) ReportMatchScore(tournamentID domain.TournamentID, matchID domain.MatchID, userID domain.TelegramUserID, games []domain.GameScore) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := t.FindParticipantBytgID(userID)
+	if p == nil {
+		return nil, domain.ErrParticipantNotInMatch
+	}
+	if !t.CanReport(p, userID) {
+		return nil, domain.ErrNotCaptain
+	}
+	if m := t.FindCurrentMatch(p.ID); m != nil && m.ID == matchID && m.P2 == p.ID {
+		games = domain.SwapScore(games)
+	}
+
+	if t.Status != domain.StatusRunning {
+		return nil, domain.ErrNotRunning
+	}
+	round, finished := t.CurrentRound, t.Finished()
+	drawErr := t.ReportScore(matchID, p.ID, games)
+	if drawErr != nil && !errors.Is(drawErr, domain.ErrNoLegalPairing) {
+		return nil, drawErr
+	}
+
+	if err := s.saveRated(t); err != nil {
+		return nil, err
+	}
+	s.announce(t, round, finished)
+
+	return t, drawErr
+}
+
+func (s *Service) WithdrawFromTournament(tournamentID domain.TournamentID, userID domain.TelegramUserID) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	p := t.FindParticipantBytgID(userID)
+	if p == nil {
+		return nil, domain.ErrNotParticipant
+	}
+	if t.TeamMode && p.Captain != userID {
+		return nil, domain.ErrCaptainWithdrawsTeam
+	}
+
+	if t.Status.Preparing() {
+		return s.leave(t, p.ID)
+	}
+	return s.withdraw(t, p.ID)
+}
+
+func (s *Service) RemoveParticipant(tournamentID domain.TournamentID, adminID domain.TelegramUserID, pID domain.ParticipantID) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermParticipants) {
+		return nil, domain.ErrForbidden
+	}
+
+	if t.Status.Preparing() {
+		return s.leave(t, pID)
+	}
+	return s.withdraw(t, pID)
+}
+
+// leave frees the seat of a participant before the start for the next one
+// on the waitlist.
+func (s *Service) leave(t *domain.Tournament, pID domain.ParticipantID) (*domain.Tournament, error) {
+	if err := t.Leave(pID); err != nil {
+		return nil, err
+	}
+	if err := s.promoteWaitlist(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (s *Service) withdraw(t *domain.Tournament, pID domain.ParticipantID) (*domain.Tournament, error) {
+	if t.Status != domain.StatusRunning {
+		return nil, domain.ErrNotRunning
+	}
+	round, finished := t.CurrentRound, t.Finished()
+	drawErr := t.Withdraw(pID)
+	if drawErr != nil && !errors.Is(drawErr, domain.ErrNoLegalPairing) {
+		return nil, drawErr
+	}
+
+	if err := s.saveRated(t); err != nil {
+		return nil, err
+	}
+	s.announce(t, round, finished)
+
+	return t, drawErr
+}
+
+func (s *Service) SetMatchScoreByAdmin(tournamentID domain.TournamentID, matchID domain.MatchID, adminID domain.TelegramUserID, games []domain.GameScore) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermResults) {
+		return nil, domain.ErrForbidden
+	}
+
+	if t.Status != domain.StatusRunning {
+		return nil, domain.ErrNotRunning
+	}
+	round, finished := t.CurrentRound, t.Finished()
+	drawErr := t.SetMatchScoreByAdmin(matchID, games)
+	if drawErr != nil && !errors.Is(drawErr, domain.ErrNoLegalPairing) {
+		return nil, drawErr
+	}
+
+	if err := s.saveRated(t); err != nil {
+		return nil, err
+	}
+	s.announce(t, round, finished)
+
+	return t, drawErr
+}
+
+func (s *Service) ResolveConflict(tournamentID domain.TournamentID, matchID domain.MatchID, adminID domain.TelegramUserID, resolution domain.Resolution, note string) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermResults) {
+		return nil, domain.ErrForbidden
+	}
+
+	if t.Status != domain.StatusRunning {
+		return nil, domain.ErrNotRunning
+	}
+	round, finished := t.CurrentRound, t.Finished()
+	drawErr := t.ResolveConflict(matchID, resolution, note, adminID)
+	if drawErr != nil && !errors.Is(drawErr, domain.ErrNoLegalPairing) {
+		return nil, drawErr
+	}
+
+	if err := s.saveRated(t); err != nil {
+		return nil, err
+	}
+	s.announce(t, round, finished)
+
+	return t, drawErr
+}
+
+// AddEvidence attaches a comment or a photo of a player to their match.
+func (s *Service) AddEvidence(e *domain.Evidence) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(e.TournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Status != domain.StatusRunning {
+		return nil, domain.ErrNotRunning
+	}
+
+	p := t.FindParticipantBytgID(e.UserID)
+	m := t.FindMatch(e.MatchID)
+	if p == nil || m == nil || (m.P1 != p.ID && m.P2 != p.ID) {
+		return nil, domain.ErrParticipantNotInMatch
+	}
+
+	e.CreatedAt = time.Now()
+	if err := s.store.AddEvidence(e); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (s *Service) GetEvidence(tid domain.TournamentID, mid domain.MatchID, adminID domain.TelegramUserID) ([]*domain.Evidence, error) {
+	if err := s.authorize(tid, adminID, domain.PermResults); err != nil {
+		return nil, err
+	}
+	return s.store.GetEvidence(tid, mid)
+}
+
+func (s *Service) SetMatchResultByAdmin(tournamentID domain.TournamentID, matchID domain.MatchID, adminID domain.TelegramUserID, result domain.ResultType) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermResults) {
+		return nil, domain.ErrForbidden
+	}
+
+	if t.Status != domain.StatusRunning {
+		return nil, domain.ErrNotRunning
+	}
+	round, finished := t.CurrentRound, t.Finished()
+	drawErr := t.SetMatchResultByAdmin(matchID, result)
+	if drawErr != nil && !errors.Is(drawErr, domain.ErrNoLegalPairing) {
+		return nil, drawErr
+	}
+
+	if err := s.saveRated(t); err != nil {
+		return nil, err
+	}
+	s.announce(t, round, finished)
+
+	return t, drawErr
+}
+
+func (s *Service) ForfeitMatchByAdmin(tournamentID domain.TournamentID, matchID domain.MatchID, adminID domain.TelegramUserID, result domain.ResultType) (*domain.Tournament, error) {
+	t, err := s.store.GetTournament(tournamentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.Can(adminID, domain.PermResults) {
+		return nil, domain.ErrForbidden
+	}
+
+	if t.Status != domain.StatusRunning {
+		return nil, domain.ErrNotRunning
+	}
+	round, finished := t.CurrentRound, t.Finished()
+	drawErr := t.ForfeitMatch(matchID, result)
+	if drawErr != nil && !errors.Is(drawErr, domain.ErrNoLegalPairing) {
+		return nil, drawErr
+	}
+
+	if err := s.saveRated(t); err != nil {
+		return nil, err
+	}
+	s.announce(t, round, finished)
+
+	return t, drawErr
+}
+
+// saveRated saves the tournament and updates player ratings with the
+// matches completed since the last save.
+func (s *Service) saveRated(t *domain.Tournament) error {
+	var userIDs []domain.TelegramUserID
+	for _, p := range t.Participants {
+		userIDs = append(userIDs, p.Roster...)
+	}
+	ratings, err := s.store.GetPlayerRatings(t.Game, userIDs)
+	if err != nil {
+		return err
+	}
+
+	changes := t.RateMatches(ratings)
+	if len(changes) == 0 {
+		return s.store.SaveTournament(t)
+	}
+
+	var changed []*domain.PlayerRating
+	seen := make(map[domain.TelegramUserID]bool)
+	for _, c := range changes {
+		if !seen[c.UserID] {
+			seen[c.UserID] = true
+			changed = append(changed, ratings[c.UserID])
+		}
+	}
+	return s.store.SaveTournamentRatings(t, changed, changes)
+}
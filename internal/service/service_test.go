@@ -0,0 +1,247 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+	"github.com/Ycyken/tournament-bot/internal/store"
+)
+
+// fakeStore keeps one tournament and its pending applications in memory.
+// Methods the tests do not reach are left to the embedded nil Store.
+type fakeStore struct {
+	store.Store
+	t        *domain.Tournament
+	apps     []*domain.Application
+	approved []domain.TelegramUserID
+	rejected []domain.TelegramUserID
+}
+
+func (f *fakeStore) GetTournament(id domain.TournamentID) (*domain.Tournament, error) {
+	return f.t, nil
+}
+
+func (f *fakeStore) SaveTournament(t *domain.Tournament) error {
+	f.t = t
+	return nil
+}
+
+func (f *fakeStore) GetApplications(tID domain.TournamentID) ([]*domain.Application, error) {
+	return f.apps, nil
+}
+
+func (f *fakeStore) GetApplication(tID domain.TournamentID, uid domain.TelegramUserID) (*domain.Application, error) {
+	for _, app := range f.apps {
+		if app.TelegramUserID == uid {
+			return app, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeStore) GetPlayerRatings(game string, userIDs []domain.TelegramUserID) (map[domain.TelegramUserID]*domain.PlayerRating, error) {
+	return map[domain.TelegramUserID]*domain.PlayerRating{}, nil
+}
+
+func (f *fakeStore) ApproveApplications(t *domain.Tournament, apps []*domain.Application) error {
+	f.t = t
+	for _, app := range apps {
+		f.approved = append(f.approved, f.decide(app.TelegramUserID))
+	}
+	return nil
+}
+
+func (f *fakeStore) RejectApplications(tID domain.TournamentID, userIDs []domain.TelegramUserID, reason string) error {
+	for _, uid := range userIDs {
+		f.rejected = append(f.rejected, f.decide(uid))
+	}
+	return nil
+}
+
+func (f *fakeStore) DeleteStaffMember(tID domain.TournamentID, uid domain.TelegramUserID) error {
+	var rest []*domain.StaffMember
+	for _, m := range f.t.Staff {
+		if m.UserID != uid {
+			rest = append(rest, m)
+		}
+	}
+	f.t.Staff = rest
+	return nil
+}
+
+// decide takes the application of uid out of the queue.
+func (f *fakeStore) decide(uid domain.TelegramUserID) domain.TelegramUserID {
+	var rest []*domain.Application
+	for _, app := range f.apps {
+		if app.TelegramUserID != uid {
+			rest = append(rest, app)
+		}
+	}
+	f.apps = rest
+	return uid
+}
+
+type fakeNotifier struct {
+	promoted []domain.TelegramUserID
+}
+
+func (n *fakeNotifier) RoundDrawn(t *domain.Tournament)         {}
+func (n *fakeNotifier) TournamentFinished(t *domain.Tournament) {}
+func (n *fakeNotifier) WaitlistPromoted(t *domain.Tournament, app *domain.Application) {
+	n.promoted = append(n.promoted, app.TelegramUserID)
+}
+
+const (
+	owner     domain.TelegramUserID = 1
+	organizer domain.TelegramUserID = 2
+	judge     domain.TelegramUserID = 3
+)
+
+// newFakeStore opens registration of a tournament with a co-organizer, a
+// judge and an application from each of the applicants, in that order.
+func newFakeStore(capacity int, applicants ...domain.TelegramUserID) *fakeStore {
+	t := domain.NewTournament(owner, "open", domain.Swiss)
+	t.ID = 1
+	t.Status = domain.StatusRegistration
+	t.Capacity = capacity
+	t.Staff = []*domain.StaffMember{{UserID: organizer, Role: domain.RoleOrganizer}, {UserID: judge, Role: domain.RoleJudge}}
+
+	f := &fakeStore{t: t}
+	created := time.Date(2025, 5, 1, 18, 0, 0, 0, time.UTC)
+	for i, uid := range applicants {
+		f.apps = append(f.apps, &domain.Application{
+			TournamentID:   t.ID,
+			TelegramUserID: uid,
+			Name:           "player",
+			CreatedAt:      created.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	return f
+}
+
+func TestService_ApproveApplications(t *testing.T) {
+	f := newFakeStore(2, 10, 11, 12)
+	svc := New(f)
+
+	if _, err := svc.ApproveApplications(1, judge, []domain.TelegramUserID{10}); err != domain.ErrForbidden {
+		t.Fatalf("expected a judge to be forbidden, got %v", err)
+	}
+	if _, err := svc.ApproveApplications(1, organizer, []domain.TelegramUserID{10, 99}); err != domain.ErrApplicationNotFound {
+		t.Fatalf("expected ErrApplicationNotFound, got %v", err)
+	}
+	if len(f.approved) != 0 || len(f.t.Participants) != 0 {
+		t.Fatalf("expected nothing to be approved when one application is missing")
+	}
+
+	approved, err := svc.ApproveApplications(1, organizer, []domain.TelegramUserID{12, 10, 11})
+	if err != nil {
+		t.Fatalf("ApproveApplications() error = %v", err)
+	}
+	if len(approved) != 2 || approved[0].TelegramUserID != 12 || approved[1].TelegramUserID != 10 {
+		t.Fatalf("expected the first two in the given order to be approved, got %v", f.approved)
+	}
+	if len(f.t.Participants) != 2 || f.t.Participants[0].ID == f.t.Participants[1].ID {
+		t.Fatalf("expected two participants with their own IDs, got %d", len(f.t.Participants))
+	}
+	if len(f.apps) != 1 || f.apps[0].TelegramUserID != 11 {
+		t.Fatalf("expected the application that did not fit to stay pending")
+	}
+
+	if _, err := svc.ApproveApplications(1, organizer, []domain.TelegramUserID{11}); err != domain.ErrTournamentFull {
+		t.Fatalf("expected ErrTournamentFull, got %v", err)
+	}
+}
+
+func TestService_RejectApplications(t *testing.T) {
+	f := newFakeStore(0, 10, 11, 12)
+	svc := New(f)
+
+	if _, err := svc.RejectApplications(1, organizer, []domain.TelegramUserID{10, 99}); err != domain.ErrApplicationNotFound {
+		t.Fatalf("expected ErrApplicationNotFound, got %v", err)
+	}
+	if len(f.rejected) != 0 {
+		t.Fatalf("expected nothing to be rejected when one application is missing")
+	}
+
+	rejected, err := svc.RejectApplications(1, owner, []domain.TelegramUserID{10, 12})
+	if err != nil {
+		t.Fatalf("RejectApplications() error = %v", err)
+	}
+	if len(rejected) != 2 || len(f.apps) != 1 || f.apps[0].TelegramUserID != 11 {
+		t.Fatalf("expected two applications to be rejected and one to stay, got %v", f.rejected)
+	}
+	if len(f.t.Participants) != 0 {
+		t.Fatalf("expected rejected applicants not to become participants")
+	}
+}
+
+func TestService_WaitlistPromotion(t *testing.T) {
+	f := newFakeStore(1, 10, 11, 12)
+	f.apps[1].Waitlisted, f.apps[2].Waitlisted = true, true
+	f.apps[1].CreatedAt, f.apps[2].CreatedAt = f.apps[2].CreatedAt, f.apps[1].CreatedAt
+	notifier := &fakeNotifier{}
+	svc := New(f)
+	svc.SetNotifier(notifier)
+
+	if err := svc.ApproveApplication(1, organizer, 10); err != nil {
+		t.Fatalf("ApproveApplication() error = %v", err)
+	}
+	left := f.t.Participants[0].ID
+
+	// 12 has waited longer than 11
+	if _, err := svc.RemoveParticipant(1, organizer, left); err != nil {
+		t.Fatalf("RemoveParticipant() error = %v", err)
+	}
+	if len(notifier.promoted) != 1 || notifier.promoted[0] != 12 {
+		t.Fatalf("expected the longest waiting applicant to be promoted, got %v", notifier.promoted)
+	}
+	if len(f.t.Participants) != 1 || f.t.Participants[0].ID == left {
+		t.Fatalf("expected the promoted applicant to get a new ID")
+	}
+
+	if _, err := svc.SetCapacity(1, organizer, 5); err != nil {
+		t.Fatalf("SetCapacity() error = %v", err)
+	}
+	if len(notifier.promoted) != 2 || notifier.promoted[1] != 11 || len(f.t.Participants) != 2 {
+		t.Fatalf("expected a raised limit to let the rest of the waitlist in, got %v", notifier.promoted)
+	}
+	if len(f.apps) != 0 {
+		t.Fatalf("expected the waitlist to be empty")
+	}
+}
+
+func TestService_RolePermissions(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(svc *Service, uid domain.TelegramUserID) error
+		may  []domain.TelegramUserID
+	}{
+		{"approve", func(svc *Service, uid domain.TelegramUserID) error {
+			return svc.ApproveApplication(1, uid, 10)
+		}, []domain.TelegramUserID{owner, organizer}},
+		{"reject", func(svc *Service, uid domain.TelegramUserID) error {
+			return svc.RejectApplication(1, uid, 10, "")
+		}, []domain.TelegramUserID{owner, organizer}},
+		{"capacity", func(svc *Service, uid domain.TelegramUserID) error {
+			_, err := svc.SetCapacity(1, uid, 8)
+			return err
+		}, []domain.TelegramUserID{owner, organizer}},
+		{"staff", func(svc *Service, uid domain.TelegramUserID) error {
+			return svc.RemoveStaffMember(1, uid, judge)
+		}, []domain.TelegramUserID{owner}},
+	}
+
+	for _, tt := range tests {
+		for _, uid := range []domain.TelegramUserID{owner, organizer, judge, 10} {
+			may := false
+			for _, id := range tt.may {
+				may = may || id == uid
+			}
+			f := newFakeStore(0, 10)
+			if err := tt.run(New(f), uid); may && err == domain.ErrForbidden || !may && err != domain.ErrForbidden {
+				t.Fatalf("%s by user %d: allowed = %v, got %v", tt.name, uid, may, err)
+			}
+		}
+	}
+}
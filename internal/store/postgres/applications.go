@@ -9,17 +9,21 @@ import (
 
 func (s *PostgresStore) CreateApplication(app *domain.Application) error {
 	_, err := s.db.Exec(`
-			INSERT INTO applications (tournament_id, telegram_user_id, name, telegram_tag, text)
-			VALUES ($1, $2, $3, $4, $5)
+			INSERT INTO applications (tournament_id, telegram_user_id, name, telegram_tag, text, invite_code, waitlisted)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
 			ON CONFLICT DO NOTHING
-		`, app.TournamentID, app.TelegramUserID, app.Name, app.TelegramTag, app.Text)
-	return err
+		`, app.TournamentID, app.TelegramUserID, app.Name, app.TelegramTag, app.Text, app.InviteCode, app.Waitlisted)
+	if err != nil {
+		return err
+	}
+	return s.saveApplicationAnswers(app)
 }
 
 func (s *PostgresStore) GetApplications(tournamentID domain.TournamentID) ([]*domain.Application, error) {
 	rows, err := s.db.Query(`
-		SELECT tournament_id, telegram_user_id, name, telegram_tag, text
+		SELECT tournament_id, telegram_user_id, name, telegram_tag, text, invite_code, created_at, waitlisted
 		FROM applications WHERE tournament_id = $1
+		ORDER BY created_at
 	`, tournamentID)
 	if err != nil {
 		return nil, err
@@ -28,32 +32,79 @@ func (s *PostgresStore) GetApplications(tournamentID domain.TournamentID) ([]*do
 
 	var apps []*domain.Application
 	for rows.Next() {
-		app := &domain.Application{}
-		if err := rows.Scan(&app.TournamentID, &app.TelegramUserID, &app.Name, &app.TelegramTag, &app.Text); err != nil {
+		app, err := scanApplication(rows)
+		if err != nil {
 			return nil, err
 		}
 		apps = append(apps, app)
 	}
+	rows.Close()
+
+	for _, app := range apps {
+		if err := s.loadApplicationMembers(app); err != nil {
+			return nil, err
+		}
+		if err := s.loadApplicationAnswers(app); err != nil {
+			return nil, err
+		}
+	}
 	return apps, nil
 }
 
 func (s *PostgresStore) GetApplication(tID domain.TournamentID, tgID domain.TelegramUserID) (*domain.Application, error) {
 	row := s.db.QueryRow(`
-		SELECT tournament_id, telegram_user_id, name, telegram_tag, text
+		SELECT tournament_id, telegram_user_id, name, telegram_tag, text, invite_code, created_at, waitlisted
 		FROM applications
 		WHERE tournament_id = $1 AND telegram_user_id = $2
 	`, tID, tgID)
 
-	app := &domain.Application{}
-	if err := row.Scan(&app.TournamentID, &app.TelegramUserID, &app.Name, &app.TelegramTag, &app.Text); err != nil {
+	app, err := scanApplication(row)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
 		}
 		return nil, err
 	}
+	if err := s.loadApplicationMembers(app); err != nil {
+		return nil, err
+	}
+	if err := s.loadApplicationAnswers(app); err != nil {
+		return nil, err
+	}
 	return app, nil
 }
 
+func (s *PostgresStore) GetApplicationByInviteCode(code string) (*domain.Application, error) {
+	row := s.db.QueryRow(`
+		SELECT tournament_id, telegram_user_id, name, telegram_tag, text, invite_code, created_at, waitlisted
+		FROM applications
+		WHERE invite_code = $1
+	`, code)
+
+	app, err := scanApplication(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if err := s.loadApplicationMembers(app); err != nil {
+		return nil, err
+	}
+	if err := s.loadApplicationAnswers(app); err != nil {
+		return nil, err
+	}
+	return app, nil
+}
+
+func (s *PostgresStore) AddApplicationMember(tID domain.TournamentID, captainID domain.TelegramUserID, userID domain.TelegramUserID) error {
+	_, err := s.db.Exec(`
+		INSERT INTO application_members (tournament_id, captain_id, telegram_user_id)
+		VALUES ($1, $2, $3)
+	`, tID, captainID, userID)
+	return err
+}
+
 func (s *PostgresStore) DeleteApplication(tournamentID domain.TournamentID, userID domain.TelegramUserID) error {
 	res, err := s.db.Exec(`
 		DELETE FROM applications
@@ -68,3 +119,143 @@ func (s *PostgresStore) DeleteApplication(tournamentID domain.TournamentID, user
 	}
 	return nil
 }
+
+// ApproveApplications saves t, which already has the applicants as
+// participants, and deletes their applications in one transaction.
+func (s *PostgresStore) ApproveApplications(t *domain.Tournament, apps []*domain.Application) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveTournament(tx, t); err != nil {
+		return err
+	}
+	for _, app := range apps {
+		if err := decideApplication(tx, t.ID, app.TelegramUserID, domain.ApplicationApproved, ""); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RejectApplications rejects the applications of all the users or none.
+func (s *PostgresStore) RejectApplications(tournamentID domain.TournamentID, userIDs []domain.TelegramUserID, reason string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, uid := range userIDs {
+		if err := decideApplication(tx, tournamentID, uid, domain.ApplicationRejected, reason); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// decideApplication moves the application out of the queue into the
+// decisions shown to the applicant.
+func decideApplication(tx *sql.Tx, tID domain.TournamentID, uid domain.TelegramUserID, status domain.ApplicationStatus, reason string) error {
+	var name string
+	err := tx.QueryRow(`
+		DELETE FROM applications
+		WHERE tournament_id = $1 AND telegram_user_id = $2
+		RETURNING name
+	`, tID, uid).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errors.New("application not found")
+	} else if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO application_decisions (tournament_id, telegram_user_id, name, status, reason)
+		VALUES ($1, $2, $3, $4, $5)
+	`, tID, uid, name, status, reason)
+	return err
+}
+
+func (s *PostgresStore) UpdateApplication(app *domain.Application) error {
+	res, err := s.db.Exec(`
+		UPDATE applications SET name = $3, text = $4
+		WHERE tournament_id = $1 AND telegram_user_id = $2
+	`, app.TournamentID, app.TelegramUserID, app.Name, app.Text)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return errors.New("application not found")
+	}
+	return nil
+}
+
+// GetUserApplications returns the pending applications of the user and
+// the decided ones, the latest first.
+func (s *PostgresStore) GetUserApplications(userID domain.TelegramUserID) ([]*domain.ApplicationRecord, error) {
+	rows, err := s.db.Query(`
+		SELECT a.tournament_id, t.title, a.name, 'pending', a.waitlisted, '', a.created_at::timestamptz
+		FROM applications a
+		JOIN tournaments t ON t.id = a.tournament_id
+		WHERE a.telegram_user_id = $1
+		UNION ALL
+		SELECT d.tournament_id, t.title, d.name, d.status, false, d.reason, d.decided_at
+		FROM application_decisions d
+		JOIN tournaments t ON t.id = d.tournament_id
+		WHERE d.telegram_user_id = $1 AND t.status <> 'archived'
+		ORDER BY 7 DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*domain.ApplicationRecord
+	for rows.Next() {
+		r := &domain.ApplicationRecord{}
+		if err := rows.Scan(&r.TournamentID, &r.Tournament, &r.Name, &r.Status, &r.Waitlisted, &r.Reason, &r.At); err != nil {
+			return nil, err
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanApplication(row rowScanner) (*domain.Application, error) {
+	app := &domain.Application{}
+	var inviteCode sql.NullString
+	if err := row.Scan(&app.TournamentID, &app.TelegramUserID, &app.Name, &app.TelegramTag, &app.Text, &inviteCode, &app.CreatedAt, &app.Waitlisted); err != nil {
+		return nil, err
+	}
+	app.InviteCode = inviteCode.String
+	return app, nil
+}
+
+func (s *PostgresStore) loadApplicationMembers(app *domain.Application) error {
+	rows, err := s.db.Query(`
+		SELECT telegram_user_id FROM application_members
+		WHERE tournament_id = $1 AND captain_id = $2
+		ORDER BY joined_at
+	`, app.TournamentID, app.TelegramUserID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var uid domain.TelegramUserID
+		if err := rows.Scan(&uid); err != nil {
+			return err
+		}
+		app.Members = append(app.Members, uid)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"github.com/Ycyken/tournament-bot/internal/domain"
+)
+
+func (s *PostgresStore) AddEvidence(e *domain.Evidence) error {
+	_, err := s.db.Exec(`
+		INSERT INTO match_evidence (tournament_id, match_id, telegram_user_id, text, photo_file_id, created_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6)
+	`, e.TournamentID, e.MatchID, e.UserID, e.Text, e.PhotoFileID, e.CreatedAt)
+	return err
+}
+
+func (s *PostgresStore) GetEvidence(tID domain.TournamentID, mID domain.MatchID) ([]*domain.Evidence, error) {
+	rows, err := s.db.Query(`
+		SELECT tournament_id, match_id, telegram_user_id, COALESCE(text, ''), COALESCE(photo_file_id, ''), created_at
+		FROM match_evidence
+		WHERE tournament_id = $1 AND match_id = $2
+		ORDER BY created_at, id
+	`, tID, mID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var evidence []*domain.Evidence
+	for rows.Next() {
+		e := &domain.Evidence{}
+		if err := rows.Scan(&e.TournamentID, &e.MatchID, &e.UserID, &e.Text, &e.PhotoFileID, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		evidence = append(evidence, e)
+	}
+	return evidence, rows.Err()
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"database/sql"
+	"strings"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+)
+
+// saveFormFields writes the application form anew, answers refer to the
+// fields by ID only.
+func saveFormFields(tx *sql.Tx, t *domain.Tournament) error {
+	if _, err := tx.Exec(`DELETE FROM form_fields WHERE tournament_id = $1`, t.ID); err != nil {
+		return err
+	}
+	for _, f := range t.Form {
+		_, err := tx.Exec(`
+			INSERT INTO form_fields (tournament_id, id, question, kind, options, required)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, t.ID, f.ID, f.Question, f.Kind, strings.Join(f.Options, "\n"), f.Required)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) getFormFields(tID domain.TournamentID) ([]*domain.FormField, error) {
+	rows, err := s.db.Query(`
+		SELECT id, question, kind, options, required
+		FROM form_fields WHERE tournament_id = $1
+		ORDER BY id
+	`, tID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var form []*domain.FormField
+	for rows.Next() {
+		f := &domain.FormField{}
+		var options string
+		if err := rows.Scan(&f.ID, &f.Question, &f.Kind, &options, &f.Required); err != nil {
+			return nil, err
+		}
+		if options != "" {
+			f.Options = strings.Split(options, "\n")
+		}
+		form = append(form, f)
+	}
+	return form, rows.Err()
+}
+
+func (s *PostgresStore) saveApplicationAnswers(app *domain.Application) error {
+	for id, v := range app.Answers {
+		_, err := s.db.Exec(`
+			INSERT INTO application_answers (tournament_id, telegram_user_id, field_id, value)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (tournament_id, telegram_user_id, field_id) DO UPDATE
+			    SET value = EXCLUDED.value
+		`, app.TournamentID, app.TelegramUserID, id, v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) loadApplicationAnswers(app *domain.Application) error {
+	rows, err := s.db.Query(`
+		SELECT field_id, value FROM application_answers
+		WHERE tournament_id = $1 AND telegram_user_id = $2
+	`, app.TournamentID, app.TelegramUserID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	app.Answers = make(map[int]string)
+	for rows.Next() {
+		var id int
+		var v string
+		if err := rows.Scan(&id, &v); err != nil {
+			return err
+		}
+		app.Answers[id] = v
+	}
+	return rows.Err()
+}
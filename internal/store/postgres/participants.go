@@ -2,22 +2,46 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
+
 	"github.com/Ycyken/tournament-bot/internal/domain"
 )
 
+// ErrParticipantTaken means the participant ID was given to somebody else
+// by a concurrent save, the whole save is rolled back.
+var ErrParticipantTaken = errors.New("participant list was changed concurrently, try again")
+
 func SaveTournamentParticipants(tx *sql.Tx, t *domain.Tournament) error {
+	// only the participants that left are deleted, so a save from an older
+	// copy does not drop somebody approved in the meantime
+	for _, pID := range t.Removed {
+		if _, err := tx.Exec(`
+			DELETE FROM participants WHERE tournament_id = $1 AND id = $2
+		`, t.ID, pID); err != nil {
+			return err
+		}
+	}
 
 	for _, p := range t.Participants {
-		_, err := tx.Exec(`
-			INSERT INTO participants (id, tournament_id, kind, name, telegram_tag, eliminated, score, joined_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		res, err := tx.Exec(`
+			INSERT INTO participants (id, tournament_id, kind, name, telegram_tag, captain_id, seed, rating, eliminated, withdrawn, score, joined_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 			ON CONFLICT (tournament_id, id) DO UPDATE
-			    SET eliminated = EXCLUDED.eliminated,
+			    SET seed = EXCLUDED.seed,
+			        rating = EXCLUDED.rating,
+			        eliminated = EXCLUDED.eliminated,
+			        withdrawn = EXCLUDED.withdrawn,
 			        score = EXCLUDED.score
-		`, p.ID, t.ID, p.Kind, p.Name, p.TelegramTag, p.Eliminated, p.Score, p.JoinedAt)
+			    WHERE COALESCE(participants.captain_id, 0) = COALESCE(EXCLUDED.captain_id, 0)
+		`, p.ID, t.ID, p.Kind, p.Name, p.TelegramTag, p.Captain, p.Seed, p.Rating, p.Eliminated, p.Withdrawn, p.Score, p.JoinedAt)
 		if err != nil {
 			return err
 		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return ErrParticipantTaken
+		}
 
 		// save telegram ids (rosters)
 		for _, uid := range p.Roster {
@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"github.com/Ycyken/tournament-bot/internal/domain"
+)
+
+func (s *PostgresStore) GetPlayerRatings(game string, userIDs []domain.TelegramUserID) (map[domain.TelegramUserID]*domain.PlayerRating, error) {
+	ids := make([]int64, len(userIDs))
+	for i, id := range userIDs {
+		ids[i] = int64(id)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT telegram_user_id, game, rating, matches
+		FROM player_ratings WHERE game = $1 AND telegram_user_id = ANY($2)
+	`, game, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ratings := make(map[domain.TelegramUserID]*domain.PlayerRating)
+	for rows.Next() {
+		r := &domain.PlayerRating{}
+		if err := rows.Scan(&r.UserID, &r.Game, &r.Rating, &r.Matches); err != nil {
+			return nil, err
+		}
+		ratings[r.UserID] = r
+	}
+	return ratings, rows.Err()
+}
+
+func (s *PostgresStore) GetUserRatings(userID domain.TelegramUserID) ([]*domain.PlayerRating, error) {
+	rows, err := s.db.Query(`
+		SELECT telegram_user_id, game, rating, matches
+		FROM player_ratings WHERE telegram_user_id = $1 ORDER BY game
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ratings []*domain.PlayerRating
+	for rows.Next() {
+		r := &domain.PlayerRating{}
+		if err := rows.Scan(&r.UserID, &r.Game, &r.Rating, &r.Matches); err != nil {
+			return nil, err
+		}
+		ratings = append(ratings, r)
+	}
+	return ratings, rows.Err()
+}
+
+func (s *PostgresStore) GetRatingHistory(userID domain.TelegramUserID, limit int) ([]*domain.RatingChange, error) {
+	rows, err := s.db.Query(`
+		SELECT telegram_user_id, game, tournament_id, match_id, rating_before, rating_after, created_at
+		FROM rating_history WHERE telegram_user_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*domain.RatingChange
+	for rows.Next() {
+		c := &domain.RatingChange{}
+		if err := rows.Scan(&c.UserID, &c.Game, &c.TournamentID, &c.MatchID, &c.Before, &c.After, &c.At); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// SaveTournamentRatings saves the tournament together with the rating
+// changes of its matches, so a match is never rated twice.
+func (s *PostgresStore) SaveTournamentRatings(t *domain.Tournament, ratings []*domain.PlayerRating, changes []*domain.RatingChange) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := saveTournament(tx, t); err != nil {
+		return err
+	}
+
+	for _, r := range ratings {
+		_, err := tx.Exec(`
+			INSERT INTO player_ratings (telegram_user_id, game, rating, matches)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (telegram_user_id, game) DO UPDATE
+			    SET rating = EXCLUDED.rating,
+			        matches = EXCLUDED.matches
+		`, r.UserID, r.Game, r.Rating, r.Matches)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, c := range changes {
+		_, err := tx.Exec(`
+			INSERT INTO rating_history (telegram_user_id, game, tournament_id, match_id, rating_before, rating_after, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`, c.UserID, c.Game, c.TournamentID, c.MatchID, c.Before, c.After, c.At)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+)
+
+func (s *PostgresStore) CreateRoleInvite(code string, tID domain.TournamentID, role domain.Role) error {
+	_, err := s.db.Exec(`
+		INSERT INTO role_invites (code, tournament_id, role) VALUES ($1, $2, $3)
+	`, code, tID, role)
+	return err
+}
+
+// GetRoleInvite returns what the invite is for without using it up. A zero
+// tournament ID means there is no such invite.
+func (s *PostgresStore) GetRoleInvite(code string) (domain.TournamentID, domain.Role, error) {
+	var tID domain.TournamentID
+	var role domain.Role
+	err := s.db.QueryRow(`
+		SELECT tournament_id, role FROM role_invites WHERE code = $1
+	`, code).Scan(&tID, &role)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, "", nil
+	}
+	return tID, role, err
+}
+
+// UseRoleInvite deletes the invite and adds the staff member in one
+// transaction, so a link works only once. It reports false if the invite
+// is already gone.
+func (s *PostgresStore) UseRoleInvite(code string, tID domain.TournamentID, m *domain.StaffMember) (bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		DELETE FROM role_invites WHERE code = $1 AND tournament_id = $2 AND role = $3
+	`, code, tID, m.Role)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	if err := saveStaffMember(tx, tID, m); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func saveStaffMember(tx *sql.Tx, tID domain.TournamentID, m *domain.StaffMember) error {
+	_, err := tx.Exec(`
+		INSERT INTO tournament_roles (tournament_id, telegram_user_id, role, name)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tournament_id, telegram_user_id) DO UPDATE
+		    SET role = EXCLUDED.role,
+		        name = EXCLUDED.name
+	`, tID, m.UserID, m.Role, m.Name)
+	return err
+}
+
+func (s *PostgresStore) DeleteStaffMember(tID domain.TournamentID, userID domain.TelegramUserID) error {
+	_, err := s.db.Exec(`
+		DELETE FROM tournament_roles WHERE tournament_id = $1 AND telegram_user_id = $2
+	`, tID, userID)
+	return err
+}
+
+func (s *PostgresStore) getStaff(tID domain.TournamentID) ([]*domain.StaffMember, error) {
+	rows, err := s.db.Query(`
+		SELECT telegram_user_id, role, name
+		FROM tournament_roles WHERE tournament_id = $1
+		ORDER BY role, name
+	`, tID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var staff []*domain.StaffMember
+	for rows.Next() {
+		m := &domain.StaffMember{}
+		if err := rows.Scan(&m.UserID, &m.Role, &m.Name); err != nil {
+			return nil, err
+		}
+		staff = append(staff, m)
+	}
+	return staff, rows.Err()
+}
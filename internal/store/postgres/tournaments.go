@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"database/sql"
+	"time"
 
 	"github.com/Ycyken/tournament-bot/internal/domain"
 )
@@ -9,10 +10,16 @@ import (
 func (s *PostgresStore) CreateTournament(t *domain.Tournament) (domain.TournamentID, error) {
 	var id int64
 	err := s.db.QueryRow(`
-		INSERT INTO tournaments (owner_id, title, system, current_round, last_round, start_time)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO tournaments (owner_id, title, status, game, system, bracket_reset, double_round_robin, team_mode, roster_reports,
+		                         tie_breaks, seeding, draw_seed, best_of, deadline_policy,
+		                         points_win, points_draw, points_loss, points_bye, points_forfeit_win, points_forfeit_loss,
+		                         current_round, last_round, start_time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
 		RETURNING id
-	`, t.OwnerID, t.Title, t.System, t.CurrentRound, t.LastRound, t.StartTime).Scan(&id)
+	`, t.OwnerID, t.Title, t.Status, t.Game, t.System, t.BracketReset, t.DoubleRoundRobin, t.TeamMode, t.RosterReports,
+		domain.FormatTieBreaks(t.TieBreaks), t.Seeding, t.DrawSeed, t.BestOf, t.DeadlinePolicy,
+		t.Scoring.Win, t.Scoring.Draw, t.Scoring.Loss, t.Scoring.Bye, t.Scoring.ForfeitWin, t.Scoring.ForfeitLoss,
+		t.CurrentRound, t.LastRound, t.StartTime).Scan(&id)
 	if err != nil {
 		return 0, err
 	}
@@ -26,16 +33,69 @@ func (s *PostgresStore) SaveTournament(t *domain.Tournament) error {
 	}
 	defer tx.Rollback()
 
+	if err := saveTournament(tx, t); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func saveTournament(tx *sql.Tx, t *domain.Tournament) error {
 	// save round info
-	_, err = tx.Exec(`
+	_, err := tx.Exec(`
 		UPDATE tournaments
-		SET current_round = $1, last_round = $2
-		WHERE id = $3
-	`, t.CurrentRound, t.LastRound, t.ID)
+		SET current_round = $1, last_round = $2, tie_breaks = $3, seeding = $4,
+		    points_win = $5, points_draw = $6, points_loss = $7,
+		    points_bye = $8, points_forfeit_win = $9, points_forfeit_loss = $10,
+		    best_of = $11, team_mode = $12, roster_reports = $13, deadline_policy = $14,
+		    status = $15, capacity = $16, registration_deadline = $17, auto_approve = $18,
+		    description = $19, rules = $20, prizes = $21, location = $22, starts_at = $23
+		WHERE id = $24
+	`, t.CurrentRound, t.LastRound, domain.FormatTieBreaks(t.TieBreaks), t.Seeding,
+		t.Scoring.Win, t.Scoring.Draw, t.Scoring.Loss,
+		t.Scoring.Bye, t.Scoring.ForfeitWin, t.Scoring.ForfeitLoss,
+		t.BestOf, t.TeamMode, t.RosterReports, t.DeadlinePolicy,
+		t.Status, t.Capacity, t.RegistrationDeadline, t.AutoApprove,
+		t.Profile.Description, t.Profile.Rules, t.Profile.Prizes, t.Profile.Location, t.Profile.StartsAt, t.ID)
 	if err != nil {
 		return err
 	}
 
+	// save rounds
+	rounds := make(map[domain.Round]bool)
+	for r := range t.RoundBestOf {
+		rounds[r] = true
+	}
+	for r := range t.Deadlines {
+		rounds[r] = true
+	}
+	for round := range rounds {
+		var bestOf *int
+		if n, ok := t.RoundBestOf[round]; ok {
+			bestOf = &n
+		}
+		var deadline *time.Time
+		var expired bool
+		if d := t.Deadlines[round]; d != nil {
+			deadline, expired = &d.At, d.Expired
+		}
+		_, err := tx.Exec(`
+			INSERT INTO rounds (tournament_id, round_number, best_of, deadline, deadline_expired)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (tournament_id, round_number) DO UPDATE
+			    SET best_of = EXCLUDED.best_of,
+			        deadline = EXCLUDED.deadline,
+			        deadline_expired = EXCLUDED.deadline_expired
+		`, t.ID, round, bestOf, deadline, expired)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := saveFormFields(tx, t); err != nil {
+		return err
+	}
+
 	// save participants
 	err = SaveTournamentParticipants(tx, t)
 	if err != nil {
@@ -57,12 +117,12 @@ func (s *PostgresStore) SaveTournament(t *domain.Tournament) error {
 	}
 
 	// save byes
-	for pid := range t.Byes {
+	for round, pid := range t.Byes {
 		_, err := tx.Exec(`
-			INSERT INTO participant_byes (tournament_id, participant_id)
-			VALUES ($1, $2)
+			INSERT INTO participant_byes (tournament_id, round_number, participant_id)
+			VALUES ($1, $2, $3)
 			ON CONFLICT DO NOTHING
-		`, t.ID, pid)
+		`, t.ID, round, pid)
 		if err != nil {
 			return err
 		}
@@ -86,43 +146,81 @@ func (s *PostgresStore) SaveTournament(t *domain.Tournament) error {
 			}
 
 			_, err := tx.Exec(`
-				INSERT INTO matches (id, tournament_id, round_number, p1_id, p2_id, state, opinion_p1, opinion_p2, result, scheduled_at)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+				INSERT INTO matches (id, tournament_id, round_number, bracket, slot, p1_id, p2_id, state, opinion_p1, opinion_p2, result,
+				                     games, claim_p1, claim_p2, forfeit, rated, resolution, resolution_note, resolved_by, scheduled_at,
+				                     proposed_at, proposed_by, reminded)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+				        NULLIF($17, ''), NULLIF($18, ''), NULLIF($19, 0), $20, $21, $22, $23)
 				ON CONFLICT (id, tournament_id) DO UPDATE
 				    SET state = EXCLUDED.state,
 				        opinion_p1 = EXCLUDED.opinion_p1,
 				        opinion_p2 = EXCLUDED.opinion_p2,
 				        result = EXCLUDED.result,
-				        scheduled_at = EXCLUDED.scheduled_at
-			`, m.ID, t.ID, round, m.P1, m.P2, m.State, opinionP1, opinionP2, result, m.ScheduledAt)
+				        games = EXCLUDED.games,
+				        claim_p1 = EXCLUDED.claim_p1,
+				        claim_p2 = EXCLUDED.claim_p2,
+				        forfeit = EXCLUDED.forfeit,
+				        rated = EXCLUDED.rated,
+				        resolution = EXCLUDED.resolution,
+				        resolution_note = EXCLUDED.resolution_note,
+				        resolved_by = EXCLUDED.resolved_by,
+				        scheduled_at = EXCLUDED.scheduled_at,
+				        proposed_at = EXCLUDED.proposed_at,
+				        proposed_by = EXCLUDED.proposed_by,
+				        reminded = EXCLUDED.reminded
+			`, m.ID, t.ID, round, m.Bracket, m.Slot, m.P1, m.P2, m.State, opinionP1, opinionP2, result,
+				formatGames(m.Games), formatGames(m.ClaimP1), formatGames(m.ClaimP2), m.Forfeit, m.Rated,
+				m.Resolution, m.ResolutionNote, m.ResolvedBy, m.ScheduledAt, m.ProposedAt, m.ProposedBy, m.Reminded)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	return tx.Commit()
+	return nil
 }
 
 func (s *PostgresStore) GetTournament(id domain.TournamentID) (*domain.Tournament, error) {
 	row := s.db.QueryRow(`
-		SELECT id, owner_id, title, system, current_round, last_round, start_time
+		SELECT id, owner_id, title, status, game, system, bracket_reset, double_round_robin, team_mode, roster_reports, tie_breaks, seeding, draw_seed, best_of, deadline_policy,
+		       points_win, points_draw, points_loss, points_bye, points_forfeit_win, points_forfeit_loss,
+		       current_round, last_round, start_time, capacity, registration_deadline, auto_approve,
+		       description, rules, prizes, location, starts_at
 		FROM tournaments WHERE id = $1
 	`, id)
 
 	t := &domain.Tournament{
-		Matches:   make(map[domain.Round][]*domain.Match),
-		Opponents: make(map[domain.ParticipantID]map[domain.ParticipantID]bool),
-		Byes:      make(map[domain.ParticipantID]bool),
+		RoundBestOf: make(map[domain.Round]int),
+		Deadlines:   make(map[domain.Round]*domain.Deadline),
+		Matches:     make(map[domain.Round][]*domain.Match),
+		Opponents:   make(map[domain.ParticipantID]map[domain.ParticipantID]bool),
+		Byes:        make(map[domain.Round]domain.ParticipantID),
+	}
+
+	var tieBreaks string
+	if err := row.Scan(&t.ID, &t.OwnerID, &t.Title, &t.Status, &t.Game, &t.System, &t.BracketReset, &t.DoubleRoundRobin, &t.TeamMode, &t.RosterReports, &tieBreaks, &t.Seeding, &t.DrawSeed, &t.BestOf, &t.DeadlinePolicy,
+		&t.Scoring.Win, &t.Scoring.Draw, &t.Scoring.Loss, &t.Scoring.Bye, &t.Scoring.ForfeitWin, &t.Scoring.ForfeitLoss,
+		&t.CurrentRound, &t.LastRound, &t.StartTime, &t.Capacity, &t.RegistrationDeadline, &t.AutoApprove,
+		&t.Profile.Description, &t.Profile.Rules, &t.Profile.Prizes, &t.Profile.Location, &t.Profile.StartsAt); err != nil {
+		return nil, err
 	}
+	t.TieBreaks = domain.ParseTieBreaks(tieBreaks)
 
-	if err := row.Scan(&t.ID, &t.OwnerID, &t.Title, &t.System, &t.CurrentRound, &t.LastRound, &t.StartTime); err != nil {
+	staff, err := s.getStaff(t.ID)
+	if err != nil {
 		return nil, err
 	}
+	t.Staff = staff
+
+	form, err := s.getFormFields(t.ID)
+	if err != nil {
+		return nil, err
+	}
+	t.Form = form
 
 	// load participants
 	rows, err := s.db.Query(`
-		SELECT id, kind, name, telegram_tag, eliminated, score, joined_at
+		SELECT id, kind, name, telegram_tag, COALESCE(captain_id, 0), seed, rating, eliminated, withdrawn, score, joined_at
 		FROM participants WHERE tournament_id = $1 ORDER BY id
 	`, t.ID)
 	if err != nil {
@@ -132,7 +230,7 @@ func (s *PostgresStore) GetTournament(id domain.TournamentID) (*domain.Tournamen
 
 	for rows.Next() {
 		p := &domain.Participant{}
-		if err := rows.Scan(&p.ID, &p.Kind, &p.Name, &p.TelegramTag, &p.Eliminated, &p.Score, &p.JoinedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.Kind, &p.Name, &p.TelegramTag, &p.Captain, &p.Seed, &p.Rating, &p.Eliminated, &p.Withdrawn, &p.Score, &p.JoinedAt); err != nil {
 			return nil, err
 		}
 		p.TournamentID = t.ID
@@ -156,7 +254,10 @@ func (s *PostgresStore) GetTournament(id domain.TournamentID) (*domain.Tournamen
 
 	// load matches
 	matches, err := s.db.Query(`
-		SELECT id, round_number, p1_id, p2_id, state, opinion_p1, opinion_p2, result, scheduled_at
+		SELECT id, round_number, bracket, slot, p1_id, p2_id, state, opinion_p1, opinion_p2, result,
+		       games, claim_p1, claim_p2, forfeit, rated,
+		       COALESCE(resolution, ''), COALESCE(resolution_note, ''), COALESCE(resolved_by, 0), scheduled_at,
+		       proposed_at, COALESCE(proposed_by, 0), reminded
 		FROM matches WHERE tournament_id = $1
 		ORDER BY round_number, id
 	`, t.ID)
@@ -167,8 +268,11 @@ func (s *PostgresStore) GetTournament(id domain.TournamentID) (*domain.Tournamen
 
 	for matches.Next() {
 		m := &domain.Match{TournamentID: t.ID}
-		var opinionP1, opinionP2, result sql.NullString
-		if err := matches.Scan(&m.ID, &m.Round, &m.P1, &m.P2, &m.State, &opinionP1, &opinionP2, &result, &m.ScheduledAt); err != nil {
+		var opinionP1, opinionP2, result, games, claimP1, claimP2 sql.NullString
+		if err := matches.Scan(&m.ID, &m.Round, &m.Bracket, &m.Slot, &m.P1, &m.P2, &m.State, &opinionP1, &opinionP2, &result,
+			&games, &claimP1, &claimP2, &m.Forfeit, &m.Rated,
+			&m.Resolution, &m.ResolutionNote, &m.ResolvedBy, &m.ScheduledAt,
+			&m.ProposedAt, &m.ProposedBy, &m.Reminded); err != nil {
 			return nil, err
 		}
 
@@ -184,9 +288,37 @@ func (s *PostgresStore) GetTournament(id domain.TournamentID) (*domain.Tournamen
 			val := domain.ResultType(result.String)
 			m.Result = &val
 		}
+		m.Games = parseGames(games)
+		m.ClaimP1 = parseGames(claimP1)
+		m.ClaimP2 = parseGames(claimP2)
 		t.Matches[m.Round] = append(t.Matches[m.Round], m)
 	}
 
+	// load rounds
+	roundRows, err := s.db.Query(`
+		SELECT round_number, best_of, deadline, deadline_expired FROM rounds WHERE tournament_id = $1
+	`, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer roundRows.Close()
+
+	for roundRows.Next() {
+		var round domain.Round
+		var bestOf sql.NullInt64
+		var deadline sql.NullTime
+		var expired bool
+		if err := roundRows.Scan(&round, &bestOf, &deadline, &expired); err != nil {
+			return nil, err
+		}
+		if bestOf.Valid {
+			t.RoundBestOf[round] = int(bestOf.Int64)
+		}
+		if deadline.Valid {
+			t.Deadlines[round] = &domain.Deadline{At: deadline.Time, Expired: expired}
+		}
+	}
+
 	// load opponents history
 	oppRows, err := s.db.Query(`
 		SELECT participant1_id, participant2_id
@@ -214,7 +346,7 @@ func (s *PostgresStore) GetTournament(id domain.TournamentID) (*domain.Tournamen
 
 	// load byes
 	byeRows, err := s.db.Query(`
-		SELECT participant_id FROM participant_byes WHERE tournament_id = $1
+		SELECT round_number, participant_id FROM participant_byes WHERE tournament_id = $1
 	`, t.ID)
 	if err != nil {
 		return nil, err
@@ -222,11 +354,12 @@ func (s *PostgresStore) GetTournament(id domain.TournamentID) (*domain.Tournamen
 	defer byeRows.Close()
 
 	for byeRows.Next() {
+		var r domain.Round
 		var p domain.ParticipantID
-		if err := byeRows.Scan(&p); err != nil {
+		if err := byeRows.Scan(&r, &p); err != nil {
 			return nil, err
 		}
-		t.Byes[p] = true
+		t.Byes[r] = p
 	}
 
 	return t, nil
@@ -273,7 +406,8 @@ func (s *PostgresStore) GetUserTournaments(userID domain.TelegramUserID) (map[do
 		FROM tournaments t
 		LEFT JOIN participants p ON t.id = p.tournament_id
 		LEFT JOIN participant_members pm ON p.id = pm.participant_id AND p.tournament_id = pm.tournament_id
-		WHERE pm.telegram_user_id = $1 OR t.owner_id = $1
+		LEFT JOIN tournament_roles r ON t.id = r.tournament_id
+		WHERE (pm.telegram_user_id = $1 OR t.owner_id = $1 OR r.telegram_user_id = $1) AND t.status <> 'archived'
 		ORDER BY t.id
 	`, userID)
 	if err != nil {
@@ -315,3 +449,117 @@ func (s *PostgresStore) AddParticipant(p *domain.Participant) error {
 
 	return nil
 }
+
+// GetDueDeadlines returns tournaments whose current round deadline has
+// passed and has not been applied yet.
+func (s *PostgresStore) GetDueDeadlines(now time.Time) ([]domain.TournamentID, error) {
+	rows, err := s.db.Query(`
+		SELECT r.tournament_id
+		FROM rounds r
+		JOIN tournaments t ON t.id = r.tournament_id AND t.current_round = r.round_number
+		WHERE r.deadline <= $1 AND NOT r.deadline_expired AND t.status = 'running'
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []domain.TournamentID
+	for rows.Next() {
+		var id domain.TournamentID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetDueReminders returns tournaments with an unplayed match of the
+// current round scheduled before until that still has reminders to send.
+func (s *PostgresStore) GetDueReminders(until time.Time, reminders int) ([]domain.TournamentID, error) {
+	rows, err := s.db.Query(`
+		SELECT DISTINCT m.tournament_id
+		FROM matches m
+		JOIN tournaments t ON t.id = m.tournament_id AND t.current_round = m.round_number AND t.status = 'running'
+		WHERE m.scheduled_at <= $1 AND m.state <> 'completed' AND m.reminded < $2
+	`, until, reminders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []domain.TournamentID
+	for rows.Next() {
+		var id domain.TournamentID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SaveReminders stores only the reminder counters of the matches, so the
+// rest of a match reported in the meantime is not overwritten. A match
+// rescheduled since it was loaded is left alone.
+func (s *PostgresStore) SaveReminders(tID domain.TournamentID, matches []*domain.Match) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, m := range matches {
+		if _, err := tx.Exec(`
+			UPDATE matches SET reminded = $1
+			WHERE tournament_id = $2 AND id = $3 AND scheduled_at = $4
+		`, m.Reminded, tID, m.ID, m.ScheduledAt); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetExpiredRegistrations returns tournaments still taking applications
+// after their registration deadline.
+func (s *PostgresStore) GetExpiredRegistrations(now time.Time) ([]domain.TournamentID, error) {
+	rows, err := s.db.Query(`
+		SELECT id FROM tournaments
+		WHERE status = 'registration' AND registration_deadline <= $1
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []domain.TournamentID
+	for rows.Next() {
+		var id domain.TournamentID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func formatGames(games []domain.GameScore) *string {
+	if games == nil {
+		return nil
+	}
+	v := domain.FormatScore(games)
+	return &v
+}
+
+func parseGames(s sql.NullString) []domain.GameScore {
+	if !s.Valid {
+		return nil
+	}
+	games, err := domain.ParseScore(s.String)
+	if err != nil {
+		return nil
+	}
+	return games
+}
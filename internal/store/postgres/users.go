@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+)
+
+// GetUserTimezone returns the IANA time zone name of the user, or an
+// empty string if they have not chosen one.
+func (s *PostgresStore) GetUserTimezone(userID domain.TelegramUserID) (string, error) {
+	var tz string
+	err := s.db.QueryRow(`SELECT timezone FROM users WHERE telegram_user_id = $1`, userID).Scan(&tz)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return tz, err
+}
+
+func (s *PostgresStore) SetUserTimezone(userID domain.TelegramUserID, tz string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO users (telegram_user_id, timezone)
+		VALUES ($1, $2)
+		ON CONFLICT (telegram_user_id) DO UPDATE SET timezone = EXCLUDED.timezone
+	`, userID, tz)
+	return err
+}
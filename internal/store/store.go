@@ -1,6 +1,10 @@
 package store
 
-import "github.com/Ycyken/tournament-bot/internal/domain"
+import (
+	"time"
+
+	"github.com/Ycyken/tournament-bot/internal/domain"
+)
 
 type Store interface {
 	Init() error
@@ -9,11 +13,37 @@ type Store interface {
 	GetTournament(id domain.TournamentID) (*domain.Tournament, error)
 	GetUserTournaments(userID domain.TelegramUserID) (map[domain.TournamentID]string, error)
 	GetTournaments() ([]*domain.Tournament, error)
+	GetDueDeadlines(now time.Time) ([]domain.TournamentID, error)
+	GetDueReminders(until time.Time, reminders int) ([]domain.TournamentID, error)
+	SaveReminders(tID domain.TournamentID, matches []*domain.Match) error
+	GetExpiredRegistrations(now time.Time) ([]domain.TournamentID, error)
 
 	AddParticipant(p *domain.Participant) error
 
+	CreateRoleInvite(code string, tID domain.TournamentID, role domain.Role) error
+	GetRoleInvite(code string) (domain.TournamentID, domain.Role, error)
+	UseRoleInvite(code string, tID domain.TournamentID, m *domain.StaffMember) (bool, error)
+	DeleteStaffMember(tID domain.TournamentID, userID domain.TelegramUserID) error
+
+	GetUserTimezone(userID domain.TelegramUserID) (string, error)
+	SetUserTimezone(userID domain.TelegramUserID, tz string) error
+
 	CreateApplication(app *domain.Application) error
 	GetApplications(tournamentID domain.TournamentID) ([]*domain.Application, error)
 	GetApplication(tID domain.TournamentID, tgID domain.TelegramUserID) (*domain.Application, error)
+	GetApplicationByInviteCode(code string) (*domain.Application, error)
+	AddApplicationMember(tID domain.TournamentID, captainID domain.TelegramUserID, userID domain.TelegramUserID) error
 	DeleteApplication(tournamentID domain.TournamentID, userID domain.TelegramUserID) error
+	RejectApplications(tournamentID domain.TournamentID, userIDs []domain.TelegramUserID, reason string) error
+	UpdateApplication(app *domain.Application) error
+	GetUserApplications(userID domain.TelegramUserID) ([]*domain.ApplicationRecord, error)
+	ApproveApplications(t *domain.Tournament, apps []*domain.Application) error
+
+	AddEvidence(e *domain.Evidence) error
+	GetEvidence(tID domain.TournamentID, mID domain.MatchID) ([]*domain.Evidence, error)
+
+	GetPlayerRatings(game string, userIDs []domain.TelegramUserID) (map[domain.TelegramUserID]*domain.PlayerRating, error)
+	GetUserRatings(userID domain.TelegramUserID) ([]*domain.PlayerRating, error)
+	GetRatingHistory(userID domain.TelegramUserID, limit int) ([]*domain.RatingChange, error)
+	SaveTournamentRatings(t *domain.Tournament, ratings []*domain.PlayerRating, changes []*domain.RatingChange) error
 }
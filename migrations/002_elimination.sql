@@ -0,0 +1,5 @@
+ALTER TABLE participants
+    ADD COLUMN seed INT NOT NULL DEFAULT 0;
+
+ALTER TABLE matches
+    ADD COLUMN slot INT NOT NULL DEFAULT 0; -- bracket node for elimination systems, 0 for 'swiss'
@@ -0,0 +1,5 @@
+ALTER TABLE tournaments
+    ADD COLUMN bracket_reset BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE matches
+    ADD COLUMN bracket VARCHAR(20) NOT NULL DEFAULT ''; -- 'winners', 'losers', 'grand_final', '' outside double elimination
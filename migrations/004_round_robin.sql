@@ -0,0 +1,2 @@
+ALTER TABLE tournaments
+    ADD COLUMN double_round_robin BOOLEAN NOT NULL DEFAULT false;
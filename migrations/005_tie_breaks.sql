@@ -0,0 +1,2 @@
+ALTER TABLE tournaments
+    ADD COLUMN tie_breaks TEXT NOT NULL DEFAULT ''; -- comma separated, e.g. 'buchholz,median_buchholz,sonneborn_berger,wins'
@@ -0,0 +1,46 @@
+ALTER TABLE tournaments
+    ADD COLUMN points_win DECIMAL(5,2) NOT NULL DEFAULT 1.0,
+    ADD COLUMN points_draw DECIMAL(5,2) NOT NULL DEFAULT 0.5,
+    ADD COLUMN points_loss DECIMAL(5,2) NOT NULL DEFAULT 0.0,
+    ADD COLUMN points_bye DECIMAL(5,2) NOT NULL DEFAULT 1.0,
+    ADD COLUMN points_forfeit_win DECIMAL(5,2) NOT NULL DEFAULT 1.0,
+    ADD COLUMN points_forfeit_loss DECIMAL(5,2) NOT NULL DEFAULT 0.0;
+
+-- 3-1-0 scoring over a long league and quarter points do not fit DECIMAL(4,1)
+ALTER TABLE participants
+    ALTER COLUMN score TYPE DECIMAL(7,2);
+
+ALTER TABLE matches
+    ADD COLUMN forfeit BOOLEAN NOT NULL DEFAULT false;
+
+-- a participant may sit out more than one round and every bye scores,
+-- so byes are kept per round
+ALTER TABLE participant_byes
+    ADD COLUMN round_number INT;
+
+-- so far a participant sat out at most once, in the round of the
+-- tournament where they have no match
+UPDATE participant_byes b
+SET round_number = (
+    SELECT MIN(m.round_number)
+    FROM matches m
+    WHERE m.tournament_id = b.tournament_id
+      AND NOT EXISTS (
+        SELECT 1 FROM matches o
+        WHERE o.tournament_id = b.tournament_id
+          AND o.round_number = m.round_number
+          AND b.participant_id IN (o.p1_id, o.p2_id)
+      )
+);
+
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM participant_byes WHERE round_number IS NULL) THEN
+        RAISE EXCEPTION 'participant_byes: a bye has no round without a match of its participant, fix it by hand before migrating';
+    END IF;
+END $$;
+
+ALTER TABLE participant_byes
+    ALTER COLUMN round_number SET NOT NULL,
+    DROP CONSTRAINT participant_byes_pkey,
+    ADD PRIMARY KEY (tournament_id, round_number);
@@ -0,0 +1,5 @@
+ALTER TABLE tournaments
+    ADD COLUMN seeding VARCHAR(20) NOT NULL DEFAULT 'random'; -- 'random', 'manual', 'rating', 'join_order'
+
+ALTER TABLE participants
+    ADD COLUMN rating INT NOT NULL DEFAULT 0;
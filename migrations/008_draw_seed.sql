@@ -0,0 +1,2 @@
+ALTER TABLE tournaments
+    ADD COLUMN draw_seed BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,27 @@
+ALTER TABLE tournaments
+    ADD COLUMN game VARCHAR(100) NOT NULL DEFAULT '';
+
+ALTER TABLE matches
+    ADD COLUMN rated BOOLEAN NOT NULL DEFAULT false;
+
+CREATE TABLE player_ratings (
+                                telegram_user_id BIGINT NOT NULL,
+                                game VARCHAR(100) NOT NULL,
+                                rating INT NOT NULL,
+                                matches INT NOT NULL DEFAULT 0,
+                                PRIMARY KEY (telegram_user_id, game)
+);
+
+CREATE TABLE rating_history (
+                                id BIGSERIAL PRIMARY KEY,
+                                telegram_user_id BIGINT NOT NULL,
+                                game VARCHAR(100) NOT NULL,
+                                tournament_id BIGINT NOT NULL, -- kept after the tournament is deleted
+                                match_id BIGINT NOT NULL,
+                                rating_before INT NOT NULL,
+                                rating_after INT NOT NULL,
+                                created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_rating_history_user
+    ON rating_history(telegram_user_id, created_at);
@@ -0,0 +1,14 @@
+ALTER TABLE tournaments
+    ADD COLUMN best_of INT NOT NULL DEFAULT 1;
+
+ALTER TABLE matches
+    ADD COLUMN games TEXT,    -- '11:9 8:11 11:5', P1 first
+    ADD COLUMN claim_p1 TEXT, -- score reported by each side
+    ADD COLUMN claim_p2 TEXT;
+
+CREATE TABLE rounds (
+                        tournament_id BIGINT NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+                        round_number INT NOT NULL,
+                        best_of INT NOT NULL,
+                        PRIMARY KEY (tournament_id, round_number)
+);
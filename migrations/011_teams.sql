@@ -0,0 +1,18 @@
+ALTER TABLE tournaments
+    ADD COLUMN team_mode BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN roster_reports BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE participants
+    ADD COLUMN captain_id BIGINT;
+
+ALTER TABLE applications
+    ADD COLUMN invite_code VARCHAR(16) UNIQUE;
+
+CREATE TABLE application_members (
+                                     tournament_id BIGINT NOT NULL,
+                                     captain_id BIGINT NOT NULL,
+                                     telegram_user_id BIGINT NOT NULL,
+                                     joined_at TIMESTAMP DEFAULT NOW(),
+                                     PRIMARY KEY (tournament_id, telegram_user_id),
+                                     FOREIGN KEY (tournament_id, captain_id) REFERENCES applications(tournament_id, telegram_user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE participants
+    ADD COLUMN withdrawn BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,10 @@
+ALTER TABLE tournaments
+    ADD COLUMN deadline_policy VARCHAR(20) NOT NULL DEFAULT 'escalate';
+
+ALTER TABLE rounds
+    ALTER COLUMN best_of DROP NOT NULL,
+    ADD COLUMN deadline TIMESTAMPTZ,
+    ADD COLUMN deadline_expired BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX idx_rounds_deadline
+    ON rounds(deadline) WHERE NOT deadline_expired;
@@ -0,0 +1,18 @@
+ALTER TABLE matches
+    ADD COLUMN resolution VARCHAR(10), -- 'p1', 'p2', 'both'
+    ADD COLUMN resolution_note TEXT,
+    ADD COLUMN resolved_by BIGINT;
+
+CREATE TABLE match_evidence (
+                                id BIGSERIAL PRIMARY KEY,
+                                tournament_id BIGINT NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+                                match_id BIGINT NOT NULL,
+                                telegram_user_id BIGINT NOT NULL,
+                                text TEXT,
+                                photo_file_id VARCHAR(255),
+                                created_at TIMESTAMP DEFAULT NOW(),
+                                FOREIGN KEY (match_id, tournament_id) REFERENCES matches(id, tournament_id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_match_evidence_match
+    ON match_evidence(tournament_id, match_id);
@@ -0,0 +1,13 @@
+ALTER TABLE matches
+    ALTER COLUMN scheduled_at TYPE TIMESTAMPTZ,
+    ADD COLUMN proposed_at TIMESTAMPTZ,
+    ADD COLUMN proposed_by BIGINT,
+    ADD COLUMN reminded INT NOT NULL DEFAULT 0;
+
+CREATE INDEX idx_matches_scheduled_at
+    ON matches(scheduled_at) WHERE scheduled_at IS NOT NULL AND state <> 'completed';
+
+CREATE TABLE users (
+                       telegram_user_id BIGINT PRIMARY KEY,
+                       timezone VARCHAR(64) NOT NULL
+);
@@ -0,0 +1,17 @@
+CREATE TABLE tournament_roles (
+                                  tournament_id BIGINT NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+                                  telegram_user_id BIGINT NOT NULL,
+                                  role VARCHAR(20) NOT NULL, -- 'organizer', 'judge'
+                                  name VARCHAR(255) NOT NULL,
+                                  PRIMARY KEY (tournament_id, telegram_user_id)
+);
+
+CREATE INDEX idx_tournament_roles_user
+    ON tournament_roles(telegram_user_id);
+
+CREATE TABLE role_invites (
+                              code VARCHAR(16) PRIMARY KEY,
+                              tournament_id BIGINT NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+                              role VARCHAR(20) NOT NULL,
+                              created_at TIMESTAMP DEFAULT NOW()
+);
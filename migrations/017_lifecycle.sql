@@ -0,0 +1,9 @@
+ALTER TABLE tournaments
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'draft';
+
+-- tournaments created before took applications right away
+UPDATE tournaments
+SET status = CASE WHEN current_round > 0 THEN 'running' ELSE 'registration' END;
+
+CREATE INDEX idx_tournaments_status
+    ON tournaments(status);
@@ -0,0 +1,10 @@
+ALTER TABLE tournaments
+    ADD COLUMN capacity INT NOT NULL DEFAULT 0, -- 0 for no limit
+    ADD COLUMN registration_deadline TIMESTAMPTZ,
+    ADD COLUMN auto_approve BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE applications
+    ADD COLUMN waitlisted BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX idx_tournaments_registration_deadline
+    ON tournaments(registration_deadline) WHERE status = 'registration';
@@ -0,0 +1,18 @@
+CREATE TABLE form_fields (
+                             tournament_id BIGINT NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+                             id INT NOT NULL,
+                             question VARCHAR(255) NOT NULL,
+                             kind VARCHAR(10) NOT NULL, -- 'text', 'number', 'choice', 'yesno'
+                             options TEXT NOT NULL DEFAULT '', -- choice: one option per line
+                             required BOOLEAN NOT NULL DEFAULT false,
+                             PRIMARY KEY (tournament_id, id)
+);
+
+CREATE TABLE application_answers (
+                                     tournament_id BIGINT NOT NULL,
+                                     telegram_user_id BIGINT NOT NULL,
+                                     field_id INT NOT NULL,
+                                     value TEXT NOT NULL,
+                                     PRIMARY KEY (tournament_id, telegram_user_id, field_id),
+                                     FOREIGN KEY (tournament_id, telegram_user_id) REFERENCES applications(tournament_id, telegram_user_id) ON DELETE CASCADE
+);
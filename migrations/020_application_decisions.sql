@@ -0,0 +1,14 @@
+-- decided applications are deleted from applications, their outcome is kept
+-- here for the applicant
+CREATE TABLE application_decisions (
+                                       id BIGSERIAL PRIMARY KEY,
+                                       tournament_id BIGINT NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+                                       telegram_user_id BIGINT NOT NULL,
+                                       name VARCHAR(255) NOT NULL,
+                                       status VARCHAR(10) NOT NULL, -- 'approved', 'rejected'
+                                       reason TEXT NOT NULL DEFAULT '',
+                                       decided_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_application_decisions_user
+    ON application_decisions(telegram_user_id);
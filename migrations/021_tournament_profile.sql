@@ -0,0 +1,6 @@
+ALTER TABLE tournaments
+    ADD COLUMN description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN rules TEXT NOT NULL DEFAULT '',
+    ADD COLUMN prizes TEXT NOT NULL DEFAULT '',
+    ADD COLUMN location TEXT NOT NULL DEFAULT '', -- a venue or a link
+    ADD COLUMN starts_at TIMESTAMPTZ;